@@ -29,8 +29,8 @@ var accountListCmd = &cobra.Command{
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
 		globals, _ := options.Globals(cmd)
-		kc := kayenta.NewDefaultClient(kayenta.ClientBaseURL(globals.KayentaURL))
-		credentials, err := kc.GetCredentials()
+		kc := kayenta.NewDefaultClient(globals.ClientOptions()...)
+		credentials, err := kc.GetCredentials(cmd.Context())
 		if err != nil {
 			log.Fatalf("could not get list of available accounts: %s", err.Error())
 		}
@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"context"
+	"time"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/fatih/color"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// cancelTimeout bounds the cancellation request made when start is interrupted,
+// since by then the command's own context has already been cancelled
+const cancelTimeout = 30 * time.Second
+
+// cancelCmd represents the cancel command
+var cancelCmd = &cobra.Command{
+	Use:   "cancel [execution-id]",
+	Short: "cancel a running analysis",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd.Root())
+
+		kc := kayenta.NewDefaultClient(globals.ClientOptions()...)
+		executionID := args[0]
+		if err := kc.CancelStandaloneCanaryAnalysis(cmd.Context(), executionID); err != nil {
+			exitcode.Exit(exitcode.FromError(err), "failed to cancel analysis: %s", err.Error())
+		}
+		log.Infof("Cancelled analysis execution %s", color.GreenString(executionID))
+	},
+}
+
+// cancelAnalysis cancels the remote execution after kayentactl has stopped
+// waiting for it, so that it doesn't keep running in kayenta unattended
+func cancelAnalysis(kc kayenta.StandaloneCanaryAnalysisAPI, executionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	log.Warnf("Cancelling analysis execution %s", executionID)
+	if err := kc.CancelStandaloneCanaryAnalysis(ctx, executionID); err != nil {
+		log.Errorf("failed to cancel analysis execution %s: %s", executionID, err.Error())
+	}
+}
+
+func init() {
+	analysisCmd.AddCommand(cancelCmd)
+}
@@ -18,43 +18,44 @@ package analysis
 import (
 	"os"
 
+	"github.com/armory-io/kayentactl/internal/analysis"
+	"github.com/armory-io/kayentactl/internal/exitcode"
 	"github.com/armory-io/kayentactl/internal/options"
 
 	"github.com/armory-io/kayentactl/internal/report"
 
 	"github.com/armory-io/kayentactl/pkg/kayenta"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-var outFormat string
+var outFormat, templateFile string
 
 // getCmd represents the get command
 var getCmd = &cobra.Command{
 	Use:   "get [execution-id]",
+	Args:  cobra.ExactArgs(1),
 	Short: "",
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
 		globals, _ := options.Globals(cmd.Root())
 
-		kc := kayenta.NewDefaultClient(kayenta.ClientBaseURL(globals.KayentaURL))
+		kc := kayenta.NewDefaultClient(globals.ClientOptions()...)
 		executionID := args[0]
 		if executionID == "" {
-			log.Fatal("execution id is required")
+			exitcode.Exit(exitcode.ClientError, "execution id is required")
 		}
-		result, err := kc.GetStandaloneCanaryAnalysis(executionID)
+		result, err := kc.GetStandaloneCanaryAnalysis(cmd.Context(), executionID)
 		if err != nil {
-			log.Fatalf("failed to fetch results of analysis: %s", err.Error())
+			exitcode.Exit(exitcode.FromError(err), "failed to fetch results of analysis: %s", err.Error())
 		}
 
-		if err := report.Report(result, outFormat, os.Stdout); err != nil {
+		if err := report.Report(result, outFormat, os.Stdout, report.WithTemplateFile(templateFile)); err != nil {
 			if err == report.ErrNotComplete {
-				log.Errorf("cannot generate report for running analysis %s", executionID)
-			} else {
-				log.Errorf("failed to generate result report: %s", err.Error())
+				exitcode.Exit(exitcode.Running, "cannot generate report for running analysis %s", executionID)
 			}
-			os.Exit(1)
+			exitcode.Exit(exitcode.ClientError, "failed to generate result report: %s", err.Error())
 		}
+		os.Exit(analysis.ExitCode(result))
 	},
 }
 
@@ -70,5 +71,6 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// getCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	getCmd.Flags().StringVarP(&outFormat, "output", "o", "pretty", "output format: json|pretty")
+	getCmd.Flags().StringVarP(&outFormat, "output", "o", "pretty", "output format: json|pretty|junit|markdown|html|template")
+	getCmd.Flags().StringVar(&templateFile, "template", "", "Go template file rendered by the template output format")
 }
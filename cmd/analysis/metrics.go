@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/armory-io/kayentactl/internal/report"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsRun            int
+	metricsStorageAccount string
+	metricsChart          string
+	chartOptions          report.ChartOptions
+)
+
+// metricsCmd represents the metrics command
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [execution-id] [metric]",
+	Short: "chart the control and experiment series of the metrics of an analysis",
+	Long: `Fetches the metric set pair list of a canary run of the analysis and draws the
+control and experiment series of each metric, or only of the given metric, as a
+line chart and box plots. The last canary run is used unless --run is given.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd.Root())
+		switch metricsChart {
+		case "line":
+			chartOptions.Line = true
+		case "box":
+			chartOptions.Box = true
+		case "both":
+			chartOptions.Line, chartOptions.Box = true, true
+		default:
+			exitcode.Exit(exitcode.ClientError, "unknown chart %q, must be one of line, box or both", metricsChart)
+		}
+		if err := chartOptions.Validate(); err != nil {
+			exitcode.Exit(exitcode.ClientError, err.Error())
+		}
+
+		kc := kayenta.NewDefaultClient(globals.ClientOptions()...)
+		executionID := args[0]
+		result, err := kc.GetStandaloneCanaryAnalysis(cmd.Context(), executionID)
+		if err != nil {
+			exitcode.Exit(exitcode.FromError(err), "failed to fetch analysis: %s", err.Error())
+		}
+
+		runs := result.CanaryAnalysisExecutionResult.CanaryExecutionResults
+		if len(runs) == 0 {
+			exitcode.Exit(exitcode.Running, "analysis %s has no completed canary runs yet", executionID)
+		}
+		run := len(runs)
+		if metricsRun != 0 {
+			run = metricsRun
+		}
+		if run < 1 || run > len(runs) {
+			exitcode.Exit(exitcode.ClientError, "analysis %s has %d canary runs, --run must be between 1 and %d", executionID, len(runs), len(runs))
+		}
+		canaryRun := runs[run-1]
+		if canaryRun.MetricSetPairListID == "" {
+			exitcode.Exit(exitcode.ExecutionError, "canary run %d of analysis %s has no metric set pair list", run, executionID)
+		}
+
+		storageAccount := metricsStorageAccount
+		if storageAccount == "" {
+			storageAccount = result.StorageAccountName
+		}
+		pairs, err := kc.GetMetricSetPairList(cmd.Context(), canaryRun.MetricSetPairListID, storageAccount)
+		if err != nil {
+			exitcode.Exit(exitcode.FromError(err), "failed to fetch metric set pair list: %s", err.Error())
+		}
+
+		if len(args) == 2 {
+			pairs = filterPairs(pairs, args[1])
+		}
+
+		results := map[string]*kayenta.MetricResult{}
+		for i, metric := range canaryRun.Result.JudgeResult.Results {
+			results[metric.Name] = &canaryRun.Result.JudgeResult.Results[i]
+		}
+
+		fmt.Printf("Canary run %d of %d\n\n", run, len(runs))
+		for _, pair := range pairs {
+			fmt.Println(report.MetricChart(pair, results[pair.Name], chartOptions))
+		}
+	},
+}
+
+// filterPairs keeps the pair of the named metric, exiting if there is none
+func filterPairs(pairs []kayenta.MetricSetPair, metric string) []kayenta.MetricSetPair {
+	var names []string
+	for _, pair := range pairs {
+		if pair.Name == metric {
+			return []kayenta.MetricSetPair{pair}
+		}
+		names = append(names, pair.Name)
+	}
+	sort.Strings(names)
+	exitcode.Exit(exitcode.ClientError, "metric %q not found, the analysis has: %s", metric, strings.Join(names, ", "))
+	return nil
+}
+
+func init() {
+	analysisCmd.AddCommand(metricsCmd)
+	flags := metricsCmd.Flags()
+	flags.IntVar(&metricsRun, "run", 0, "canary run to chart, starting at 1. defaults to the last run")
+	flags.StringVar(&metricsStorageAccount, "storage-account", "", "storage account the metric set pair list is stored in. defaults to the analysis' storage account")
+	flags.StringVar(&metricsChart, "chart", "both", "charts to draw: line|box|both")
+	flags.IntVar(&chartOptions.Width, "width", 60, "width of the charts")
+	flags.IntVar(&chartOptions.Height, "height", 12, "height of the line chart")
+}
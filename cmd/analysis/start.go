@@ -17,11 +17,15 @@ package analysis
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	osuser "os/user"
 	"strings"
 	"time"
 
+	"github.com/armory-io/kayentactl/internal/exitcode"
 	"github.com/armory-io/kayentactl/internal/options"
 
 	"github.com/armory-io/kayentactl/internal/canaryConfig"
@@ -35,15 +39,95 @@ import (
 	"github.com/fatih/color"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-// TODO: get rid of these package global variables. it was easier to port existing code by using them.
+// flagSpec holds the values of the flags of the start command. flags that are
+// set explicitly take precedence over the run spec file given with -f.
 var (
-	scope, configLocation, control, experiment, startTimeIso, endTimeIso, thresholds, metricsAccount, storageAccount string
-	controlOffset, lifetimeDuration, analysisInterval, checkInterval, timeout                                        time.Duration
-	noWait                                                                                                           bool
+	flagSpec             analysis.RunSpec
+	specFile, thresholds string
+	scopeDefinitions     []string
 )
 
+// specFlagOverrides copies the value of a flag from src to dst, keyed by flag name
+var specFlagOverrides = map[string]func(dst, src *analysis.RunSpec){
+	"canary-config":                func(dst, src *analysis.RunSpec) { dst.CanaryConfig = src.CanaryConfig },
+	"scope":                        func(dst, src *analysis.RunSpec) { dst.Scope = src.Scope },
+	"control":                      func(dst, src *analysis.RunSpec) { dst.Control = src.Control },
+	"experiment":                   func(dst, src *analysis.RunSpec) { dst.Experiment = src.Experiment },
+	"scope-def":                    func(dst, src *analysis.RunSpec) { dst.Scopes = src.Scopes },
+	"start-time-iso":               func(dst, src *analysis.RunSpec) { dst.StartTimeIso = src.StartTimeIso },
+	"end-time-iso":                 func(dst, src *analysis.RunSpec) { dst.EndTimeIso = src.EndTimeIso },
+	"thresholds":                   func(dst, src *analysis.RunSpec) { dst.Thresholds = src.Thresholds },
+	"extended-scope-params":        func(dst, src *analysis.RunSpec) { dst.ExtendedScopeParams = src.ExtendedScopeParams },
+	"metrics-account":              func(dst, src *analysis.RunSpec) { dst.MetricsAccount = src.MetricsAccount },
+	"storage-account":              func(dst, src *analysis.RunSpec) { dst.StorageAccount = src.StorageAccount },
+	"configuration-account":        func(dst, src *analysis.RunSpec) { dst.ConfigurationAccount = src.ConfigurationAccount },
+	"user":                         func(dst, src *analysis.RunSpec) { dst.User = src.User },
+	"application":                  func(dst, src *analysis.RunSpec) { dst.Application = src.Application },
+	"parent-pipeline-execution-id": func(dst, src *analysis.RunSpec) { dst.ParentPipelineExecutionID = src.ParentPipelineExecutionID },
+	"analysis-interval":            func(dst, src *analysis.RunSpec) { dst.AnalysisInterval = src.AnalysisInterval },
+	"lifetime-duration":            func(dst, src *analysis.RunSpec) { dst.LifetimeDuration = src.LifetimeDuration },
+	"begin-after":                  func(dst, src *analysis.RunSpec) { dst.BeginAfter = src.BeginAfter },
+	"lookback":                     func(dst, src *analysis.RunSpec) { dst.Lookback = src.Lookback },
+	"control-offset":               func(dst, src *analysis.RunSpec) { dst.ControlOffset = src.ControlOffset },
+	"interval":                     func(dst, src *analysis.RunSpec) { dst.PollInterval = src.PollInterval },
+	"timeout":                      func(dst, src *analysis.RunSpec) { dst.Timeout = src.Timeout },
+	"no-wait":                      func(dst, src *analysis.RunSpec) { dst.NoWait = src.NoWait },
+	"output":                       func(dst, src *analysis.RunSpec) { dst.Output.Format = src.Output.Format },
+	"output-file":                  func(dst, src *analysis.RunSpec) { dst.Output.File = src.Output.File },
+	"template":                     func(dst, src *analysis.RunSpec) { dst.Output.Template = src.Output.Template },
+}
+
+// resolveRunSpec combines the run spec file, if any, with the flags. flags
+// that were set explicitly win over the file, which wins over flag defaults
+func resolveRunSpec(flags *pflag.FlagSet) (analysis.RunSpec, error) {
+	spec := flagSpec
+	t := processThresholds(thresholds, "50", "90")
+	spec.Thresholds = analysis.SpecThresholds{Marginal: json.Number(t.Marginal), Pass: json.Number(t.Pass)}
+	for _, definition := range scopeDefinitions {
+		parsed, err := analysis.ParseScope(definition)
+		if err != nil {
+			return analysis.RunSpec{}, err
+		}
+		spec.Scopes = append(spec.Scopes, parsed)
+	}
+
+	if specFile == "" {
+		return spec, spec.Validate()
+	}
+
+	fromFile := spec
+	if err := analysis.LoadRunSpec(specFile, &fromFile); err != nil {
+		return analysis.RunSpec{}, err
+	}
+	for name, override := range specFlagOverrides {
+		if flags.Changed(name) {
+			override(&fromFile, &spec)
+		}
+	}
+	return fromFile, fromFile.Validate()
+}
+
+// currentUser is used to attribute executions in kayenta when no user is given
+func currentUser() string {
+	u, err := osuser.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// applicationFor returns the application an execution is attributed to. if no
+// application is given, the first application of the canary config is used
+func applicationFor(application string, cc *kayenta.CanaryConfig) string {
+	if application != "" || len(cc.Applications) == 0 {
+		return application
+	}
+	return cc.Applications[0]
+}
+
 // processThresholds takes a string in the format of marginal=?,pass=? and creates
 // a kayenta.Threshold using the values. if the string is malformed or cannot be
 // processed, the defaults are used
@@ -78,110 +162,175 @@ var startCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		globals, _ := options.Globals(cmd)
 
+		spec, err := resolveRunSpec(cmd.Flags())
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, "invalid analysis settings: %s", err.Error())
+		}
+
+		// reports other than pretty are meant for machines, so when they go
+		// to stdout everything else is written to stderr
+		console := io.Writer(os.Stdout)
+		if spec.Output.Format != "pretty" && spec.Output.File == "" {
+			console = os.Stderr
+			log.SetOutput(os.Stderr)
+		}
+
+		// the report file is created up front, so that a bad path fails
+		// before the analysis runs rather than after waiting for it
+		out := io.Writer(os.Stdout)
+		if spec.Output.File != "" && !spec.NoWait {
+			f, err := os.Create(spec.Output.File)
+			if err != nil {
+				exitcode.Exit(exitcode.ClientError, "could not create report file: %s", err.Error())
+			}
+			defer f.Close()
+			out = f
+		}
+
 		if !globals.NoColor {
-			fmt.Printf("%v\n", color.HiMagentaString(report.AsciiKayenta))
+			fmt.Fprintf(console, "%v\n", color.HiMagentaString(report.AsciiKayenta))
 		}
-		kc := kayenta.NewDefaultClient(kayenta.ClientBaseURL(globals.KayentaURL))
+		kc := kayenta.NewDefaultClient(append(globals.ClientOptions(),
+			kayenta.ClientConfigurationAccountName(spec.ConfigurationAccount))...)
 
-		log.Debugf("Fetching canary config from: %s", color.BlueString(configLocation))
-		canaryConfig, err := canaryConfig.GetCanaryConfig(configLocation)
+		log.Debugf("Fetching canary config from: %s", color.BlueString(spec.CanaryConfig))
+		canaryConfig, err := canaryConfig.ResolveCanaryConfig(cmd.Context(), spec.CanaryConfig, kc)
 		if err != nil {
-			log.Fatalf("failed to fetch and parse canary config: %s", err.Error())
+			exitcode.Exit(exitcode.FromLookupError(err), "failed to fetch and parse canary config: %s", err.Error())
 		}
 
 		// if the control and experiment are the same, users
 		// can provide a single scope option for both
-		if (control == "" && experiment == "") && scope != "" {
-			control, experiment = scope, scope
+		control, experiment := spec.Control, spec.Experiment
+		if (control == "" && experiment == "") && spec.Scope != "" {
+			control, experiment = spec.Scope, spec.Scope
 		}
+
+		// a single scope is named after the only scope the config's metrics use
+		scopeName := ""
+		if names := analysis.ScopeNames(*canaryConfig); len(names) == 1 {
+			scopeName = names[0]
+		}
+
 		executionRequest, err := analysis.BuildExecutionRequest(analysis.ExecutionRequestContext{
+			ScopeName:            scopeName,
+			Scopes:               spec.Scopes,
 			ControlScope:         control,
 			ExperimentScope:      experiment,
-			StartTimeIso:         startTimeIso,
-			EndTimeIso:           endTimeIso,
-			ControlOffset:        controlOffset,
-			AnalysisIntervalMins: analysisInterval,
-			LifetimeDurationMins: lifetimeDuration,
-			Thresholds:           processThresholds(thresholds, "50", "90"),
+			StartTimeIso:         spec.StartTimeIso,
+			EndTimeIso:           spec.EndTimeIso,
+			ControlOffset:        time.Duration(spec.ControlOffset),
+			AnalysisIntervalMins: time.Duration(spec.AnalysisInterval),
+			LifetimeDurationMins: time.Duration(spec.LifetimeDuration),
+			BeginAfterMins:       time.Duration(spec.BeginAfter),
+			LookbackMins:         time.Duration(spec.Lookback),
+			ExtendedScopeParams:  spec.ExtendedScopeParams,
+			Thresholds:           spec.Thresholds.Threshold(),
 		})
 
 		if err != nil {
-			log.Fatalf("unable to create execution request: %s", err.Error())
+			exitcode.Exit(exitcode.ClientError, "unable to create execution request: %s", err.Error())
+		}
+		if err := analysis.ValidateScopes(*canaryConfig, executionRequest.Scopes); err != nil {
+			exitcode.Exit(exitcode.ClientError, "invalid scopes: %s", err.Error())
 		}
 
+		user := spec.User
+		if user == "" {
+			user = currentUser()
+		}
 		input := kayenta.StandaloneCanaryAnalysisInput{
-			ExecutionRequest:   *executionRequest,
-			CanaryConfig:       *canaryConfig,
-			MetricsAccountName: metricsAccount,
-			StorageAccountName: storageAccount,
+			ExecutionRequest:          *executionRequest,
+			CanaryConfig:              *canaryConfig,
+			User:                      user,
+			Application:               applicationFor(spec.Application, canaryConfig),
+			ParentPipelineExecutionID: spec.ParentPipelineExecutionID,
+			MetricsAccountName:        spec.MetricsAccount,
+			StorageAccountName:        spec.StorageAccount,
+			ConfigurationAccountName:  spec.ConfigurationAccount,
 		}
 
 		// start standalone canary
 		log.Debugf("Analysis Execution starting with kayenta host: %v", color.BlueString(globals.KayentaURL))
-		output, err := kc.StartStandaloneCanaryAnalysis(input)
+		output, err := kc.StartStandaloneCanaryAnalysis(cmd.Context(), input)
 		if err != nil {
-			log.Fatalf("error starting canary analysis: %s", err.Error())
+			exitcode.Exit(exitcode.FromError(err), "error starting canary analysis: %s", err.Error())
 		}
 		analysisID := output.CanaryAnalysisExecutionID
 		log.Info(fmt.Sprintf("Analysis Execution ID: %s", color.GreenString(analysisID)))
 
 		// if the no-wait flag is set, we exit early. this enables users
 		// to implement their own wait login within scripts
-		if noWait {
+		if spec.NoWait {
 			return
 		}
 
 		// poll until standalone canary is complete
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, cancel := context.WithTimeout(cmd.Context(), time.Duration(spec.Timeout))
 		defer cancel()
 
-		ticker := time.NewTicker(checkInterval)
-		progressPrinter := analysis.NewDefaultGraphicalProgressPrinter()
+		ticker := time.NewTicker(time.Duration(spec.PollInterval))
+		progressPrinter := analysis.NewGraphicalProgressPrinter(console)
 		progressPrinter.Start()
-		if err := analysis.WaitForComplete(ctx, analysisID, kc, ticker, progressPrinter.PrintProgress); err != nil {
-			log.Fatalf(err.Error())
+		if err := analysis.WaitForComplete(ctx, analysisID, kc, ticker, globals.MaxPollFailures, progressPrinter.PrintProgress); err != nil {
+			progressPrinter.Stop()
+			// the user interrupted us or the timeout elapsed, either way
+			// nobody is waiting for the execution anymore
+			if ctx.Err() != nil {
+				cancelAnalysis(kc, analysisID)
+			}
+			exitcode.Exit(exitcode.FromError(err), "analysis did not complete: %s", err.Error())
 		}
 		progressPrinter.Stop()
 
 		// generate some kind of report
-		result, err := kc.GetStandaloneCanaryAnalysis(analysisID)
+		result, err := kc.GetStandaloneCanaryAnalysis(ctx, analysisID)
 		if err != nil {
-			log.Fatalf("Failed to get analysis result: %s", err.Error())
+			exitcode.Exit(exitcode.FromError(err), "Failed to get analysis result: %s", err.Error())
 		}
 
-		if err := report.Report(result, "pretty", os.Stdout); err != nil {
-			log.Fatalf("error generating analysis report: %s", err.Error())
+		if err := report.Report(result, spec.Output.Format, out, report.WithTemplateFile(spec.Output.Template)); err != nil {
+			exitcode.Exit(exitcode.ClientError, "error generating analysis report: %s", err.Error())
 		}
 
-		fmt.Println(analysis.TableStatus(result))
-
-		exitCode := 1
-		if result.IsSuccessful() {
-			exitCode = 0
-		}
-		os.Exit(exitCode)
+		// the exit code tells scripts how the analysis went, see the
+		// exitcode package for what each code means
+		os.Exit(analysis.ExitCode(result))
 	},
 }
 
 func init() {
 	analysisCmd.AddCommand(startCmd)
 	flags := startCmd.Flags()
-	flags.StringVar(&configLocation, "canary-config", "canary.json", "location of canary configuration")
-	flags.StringVarP(&scope, "scope", "s", "", "name of the scope to use")
-	flags.StringVarP(&control, "control", "c", "", "application to use as the experiment control (i.e. baseline)")
-	flags.StringVarP(&experiment, "experiment", "e", "", "application to use as the experiment  (i.e. canary)")
-	flags.StringVar(&startTimeIso, "start-time-iso", "", "start time for the analysis in ISO format. Ex: 2020-12-20T14:49:31.647Z")
-	flags.StringVar(&endTimeIso, "end-time-iso", "", "end time for the analysis in ISO format. Ex: 2020-12-20T15:49:31.647Z")
+	flags.StringVarP(&specFile, "filename", "f", "", "run spec file with the settings for the analysis. flags that are set explicitly override the file")
+	flags.StringVar(&flagSpec.CanaryConfig, "canary-config", "canary.json", "location of canary configuration: a file, a URL, kayenta://<application>/<name> or the id of a config stored in kayenta")
+	flags.StringVarP(&flagSpec.Scope, "scope", "s", "", "name of the scope to use")
+	flags.StringVarP(&flagSpec.Control, "control", "c", "", "application to use as the experiment control (i.e. baseline)")
+	flags.StringVarP(&flagSpec.Experiment, "experiment", "e", "", "application to use as the experiment  (i.e. canary)")
+	flags.StringArrayVar(&scopeDefinitions, "scope-def", nil, "scope definition, can be repeated for configs with multiple scopes. Ex: name=default,control=us-east-1/web-baseline,experiment=us-east-1/web-canary,step=60,param.resourceType=k8s_container")
+	flags.StringVar(&flagSpec.StartTimeIso, "start-time-iso", "", "start time for the analysis in ISO format. Ex: 2020-12-20T14:49:31.647Z")
+	flags.StringVar(&flagSpec.EndTimeIso, "end-time-iso", "", "end time for the analysis in ISO format. Ex: 2020-12-20T15:49:31.647Z")
 	flags.StringVar(&thresholds, "thresholds", "marginal=50,pass=90", "comma-delimeted threshold levels")
 
-	flags.StringVar(&metricsAccount, "metrics-account", "", "metrics account name")
-	flags.StringVar(&storageAccount, "storage-account", "", "storage account name")
-
-	flags.DurationVar(&analysisInterval, "analysis-interval", 1*time.Minute, "Minutes between each analysis. Default is once per minute")
-	flags.DurationVar(&lifetimeDuration, "lifetime-duration", time.Minute*5, "Total duration time for the analysis")
-	flags.DurationVar(&checkInterval, "interval", time.Second*5, "polling interval")
-	flags.DurationVar(&timeout, "timeout", time.Hour, "timeout")
-	flags.DurationVar(&controlOffset, "control-offset", time.Hour, "The control offset to compare against the experiment, by default is your new deployment")
-
-	flags.BoolVar(&noWait, "no-wait", false, "don't wait for canary execution to complete before exiting")
+	flags.StringVar(&flagSpec.MetricsAccount, "metrics-account", "", "metrics account name")
+	flags.StringVar(&flagSpec.StorageAccount, "storage-account", "", "storage account name")
+	flags.StringVar(&flagSpec.ConfigurationAccount, "configuration-account", "", "configuration account name, also used to look up canary configs stored in kayenta")
+
+	flags.StringVar(&flagSpec.User, "user", "", "user the execution is attributed to in kayenta. defaults to the current OS user")
+	flags.StringVar(&flagSpec.Application, "application", "", "application the execution is attributed to in kayenta. defaults to the first application of the canary config")
+	flags.StringVar(&flagSpec.ParentPipelineExecutionID, "parent-pipeline-execution-id", "", "id of the pipeline execution that started the analysis")
+	flags.StringToStringVar(&flagSpec.ExtendedScopeParams, "extended-scope-params", nil, "extra scope parameters passed to the metrics provider. Ex: resourceType=k8s_container,project=my-project")
+
+	flags.DurationVar((*time.Duration)(&flagSpec.AnalysisInterval), "analysis-interval", 1*time.Minute, "Minutes between each analysis. Default is once per minute")
+	flags.DurationVar((*time.Duration)(&flagSpec.LifetimeDuration), "lifetime-duration", time.Minute*5, "Total duration time for the analysis")
+	flags.DurationVar((*time.Duration)(&flagSpec.BeginAfter), "begin-after", 0, "time to wait before the first analysis, e.g. to let the experiment warm up")
+	flags.DurationVar((*time.Duration)(&flagSpec.Lookback), "lookback", 0, "size of a sliding window of metrics to analyze at each interval instead of all metrics since the analysis began")
+	flags.DurationVar((*time.Duration)(&flagSpec.PollInterval), "interval", time.Second*5, "polling interval")
+	flags.DurationVar((*time.Duration)(&flagSpec.Timeout), "timeout", time.Hour, "time to wait for the analysis to complete before cancelling it")
+	flags.DurationVar((*time.Duration)(&flagSpec.ControlOffset), "control-offset", time.Hour, "The control offset to compare against the experiment, by default is your new deployment")
+
+	flags.BoolVar(&flagSpec.NoWait, "no-wait", false, "don't wait for canary execution to complete before exiting")
+	flags.StringVarP(&flagSpec.Output.Format, "output", "o", "pretty", "output format: json|pretty|junit|markdown|html|template")
+	flags.StringVar(&flagSpec.Output.File, "output-file", "", "write the report to a file instead of stdout")
+	flags.StringVar(&flagSpec.Output.Template, "template", "", "Go template file rendered by the template output format")
 }
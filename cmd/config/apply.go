@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/armory-io/kayentactl/internal/canaryConfig"
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/fatih/color"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyDir     string
+	applyOptions canaryConfig.ApplyOptions
+	applyDryRun  bool
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "make the canary configs in kayenta match a directory of config files",
+	Long: `Reads every .json, .yml and .yaml file in the directory as a canary config and
+reconciles kayenta with them. Configs are matched by application and name:
+missing configs are created and changed configs are updated. With --prune,
+configs of the application that aren't in the directory are deleted. Configs
+shared with other applications are kept for them, only the application is
+removed from them.
+
+The plan is printed before anything is changed. Use --dry-run to only print it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd)
+		local, err := canaryConfig.LoadDir(applyDir)
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, "failed to load canary configs: %s", err.Error())
+		}
+
+		kc := newClient(globals)
+		plan, err := canaryConfig.PlanApply(cmd.Context(), kc, local, applyOptions)
+		if err != nil {
+			exitcode.Exit(exitcode.FromLookupError(err), "could not plan changes: %s", err.Error())
+		}
+
+		printPlan(plan)
+		if applyDryRun || !plan.HasChanges() {
+			return
+		}
+
+		err = canaryConfig.Apply(cmd.Context(), kc, plan, func(change canaryConfig.Change, id string) {
+			log.Infof("%sd canary config %s of application %s (%s)", change.Action, change.Name, change.Application, id)
+		})
+		var conflict kayenta.ConflictError
+		if errors.As(err, &conflict) {
+			exitcode.Exit(exitcode.Conflict, "%s. run apply again to plan against the current config", err.Error())
+		}
+		if err != nil {
+			exitcode.Exit(exitcode.FromError(err), err.Error())
+		}
+	},
+}
+
+func printPlan(plan canaryConfig.Plan) {
+	fmt.Printf("Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		plan.Count(canaryConfig.ActionCreate), plan.Count(canaryConfig.ActionUpdate),
+		plan.Count(canaryConfig.ActionDelete), plan.Count(canaryConfig.ActionUnchanged))
+
+	for _, change := range plan.Changes {
+		var line string
+		switch change.Action {
+		case canaryConfig.ActionCreate:
+			line = color.GreenString("  + create %s/%s", change.Application, change.Name)
+		case canaryConfig.ActionUpdate:
+			line = color.YellowString("  ~ update %s/%s", change.Application, change.Name)
+		case canaryConfig.ActionDelete:
+			line = color.RedString("  - delete %s/%s", change.Application, change.Name)
+		default:
+			continue
+		}
+		if change.ID != "" {
+			line += " " + change.ID
+		}
+		if change.Path != "" {
+			line += fmt.Sprintf(" (%s)", change.Path)
+		}
+		if change.Note != "" {
+			line += fmt.Sprintf(": %s", change.Note)
+		}
+		fmt.Println(line)
+	}
+}
+
+func init() {
+	configCmd.AddCommand(applyCmd)
+	flags := applyCmd.Flags()
+	flags.StringVarP(&applyDir, "dir", "d", "", "directory of canary config files")
+	flags.StringVarP(&applyOptions.Application, "application", "a", "", "application every config is applied to, added to the applications of configs that lack it. defaults to the first application of each config")
+	flags.BoolVar(&applyOptions.Prune, "prune", false, "delete configs of the application that aren't in the directory, or remove the application from shared ones. requires --application")
+	flags.BoolVar(&applyDryRun, "dry-run", false, "only print the plan")
+	applyCmd.MarkFlagRequired("dir")
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "commands for managing the canary configs stored in kayenta",
+	Long:  ``,
+}
+
+// configurationAccount is the kayenta account the canary configs are stored in
+var configurationAccount string
+
+func Configure(cmd *cobra.Command) {
+	cmd.AddCommand(configCmd)
+}
+
+func init() {
+	configCmd.PersistentFlags().StringVar(&configurationAccount, "configuration-account", "", "configuration account the canary configs are stored in. defaults to kayenta's default account")
+}
+
+// newClient builds a kayenta client for the config commands, which all use the
+// configuration account given with --configuration-account
+func newClient(globals *options.GlobalOptions) *kayenta.DefaultClient {
+	return kayenta.NewDefaultClient(append(globals.ClientOptions(),
+		kayenta.ClientConfigurationAccountName(configurationAccount))...)
+}
+
+// render formats v as json or yaml, or with table if the format is table
+func render(format string, v interface{}, table func() string) ([]byte, error) {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(v, "", "  ")
+		return append(b, '\n'), err
+	case "yaml":
+		return yaml.Marshal(v)
+	case "table":
+		return []byte(table()), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, must be one of table, json or yaml", format)
+	}
+}
+
+func updated(cc kayenta.CanaryConfig) string {
+	if cc.UpdatedTimestampIso != "" {
+		return cc.UpdatedTimestampIso
+	}
+	if cc.UpdatedTimestamp != 0 {
+		return time.Unix(0, cc.UpdatedTimestamp*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+	}
+	return "-"
+}
+
+func tableFromConfigs(configs []kayenta.CanaryConfig) string {
+	wb := new(bytes.Buffer)
+	table := tablewriter.NewWriter(wb)
+	table.SetHeader([]string{"ID", "Name", "Applications", "Updated"})
+	table.SetAutoWrapText(false)
+	for _, cc := range configs {
+		table.Append([]string{cc.Id, cc.Name, strings.Join(cc.Applications, ", "), updated(cc)})
+	}
+	table.Render()
+	return wb.String()
+}
+
+func tableFromConfig(cc kayenta.CanaryConfig) string {
+	wb := new(bytes.Buffer)
+	fmt.Fprintf(wb, "ID:           %s\n", cc.Id)
+	fmt.Fprintf(wb, "Name:         %s\n", cc.Name)
+	if cc.Description != "" {
+		fmt.Fprintf(wb, "Description:  %s\n", cc.Description)
+	}
+	fmt.Fprintf(wb, "Applications: %s\n", strings.Join(cc.Applications, ", "))
+	fmt.Fprintf(wb, "Judge:        %s\n", cc.Judge.Name)
+	fmt.Fprintf(wb, "Updated:      %s\n\n", updated(cc))
+
+	table := tablewriter.NewWriter(wb)
+	table.SetHeader([]string{"Metric", "Groups", "Scope", "Query", "Direction", "Critical"})
+	table.SetAutoWrapText(false)
+	for _, metric := range cc.Metrics {
+		query, direction, critical := "-", "-", "false"
+		if metric.Query.Query != nil {
+			query = metric.Query.Type()
+		}
+		if canary := metric.AnalysisConfigurations.Canary; canary != nil {
+			if canary.Direction != "" {
+				direction = string(canary.Direction)
+			}
+			critical = fmt.Sprint(canary.Critical)
+		}
+		table.Append([]string{metric.Name, strings.Join(metric.Groups, ", "), metric.ScopeName, query, direction, critical})
+	}
+	table.Render()
+
+	if len(cc.Classifier.GroupWeights) > 0 {
+		fmt.Fprintln(wb)
+		weights := tablewriter.NewWriter(wb)
+		weights.SetHeader([]string{"Group", "Weight"})
+		for _, group := range sortedGroups(cc.Classifier.GroupWeights) {
+			weights.Append([]string{group, fmt.Sprint(cc.Classifier.GroupWeights[group])})
+		}
+		weights.Render()
+	}
+	return wb.String()
+}
+
+func sortedGroups(weights map[string]float64) []string {
+	var groups []string
+	for group := range weights {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete [config-id]...",
+	Short: "delete canary configs stored in kayenta",
+	Long:  ``,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd)
+		kc := newClient(globals)
+		for _, id := range args {
+			if err := kc.DeleteCanaryConfig(cmd.Context(), id); err != nil {
+				exitcode.Exit(exitcode.FromError(err), "could not delete canary config %s: %s", id, err.Error())
+			}
+			log.Infof("deleted canary config %s", id)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/armory-io/kayentactl/internal/canaryConfig"
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var diffRemote, diffApplication string
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [location]",
+	Short: "show how a canary config differs from the copy stored in kayenta",
+	Long: `Compares a canary config from a file or URL with the config stored in kayenta
+and prints the differences: metrics added or removed, changed queries and
+analysis settings, and changed group weights. Fields kayenta manages, like
+the id and timestamps, and the order of metrics are ignored.
+
+The config in kayenta is the application's config with the same name, or the
+config given by --remote, which is a name or an id. Exits with 1 if the
+configs differ, like diff.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd)
+		local, err := canaryConfig.GetCanaryConfig(args[0])
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, "failed to load canary config: %s", err.Error())
+		}
+
+		application := diffApplication
+		if application == "" && len(local.Applications) > 0 {
+			application = local.Applications[0]
+		}
+		if application != "" {
+			// the config is compared the way config push would store it
+			local.Applications = []string{application}
+		}
+		ref := diffRemote
+		if ref == "" {
+			ref = local.Name
+			if application == "" {
+				exitcode.Exit(exitcode.ClientError, "the canary config has no applications, set one with --application or use --remote")
+			}
+		}
+
+		kc := newClient(globals)
+		remote, err := canaryConfig.FindCanaryConfig(cmd.Context(), kc, application, ref)
+		if err != nil {
+			exitcode.Exit(exitcode.FromLookupError(err), "could not get canary config from kayenta: %s", err.Error())
+		}
+
+		diffs, err := canaryConfig.Diff(remote, *local)
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, "could not compare canary configs: %s", err.Error())
+		}
+		if len(diffs) == 0 {
+			fmt.Printf("%s matches canary config %s in kayenta\n", args[0], remote.Id)
+			return
+		}
+
+		fmt.Println(color.RedString("--- kayenta %s (updated %s)", remote.Id, updated(remote)))
+		fmt.Println(color.GreenString("+++ %s", args[0]))
+		for _, diff := range diffs {
+			printDifference(diff)
+		}
+		exitcode.Exit(exitcode.Differ, "%s differs from canary config %s in kayenta", args[0], remote.Id)
+	},
+}
+
+func printDifference(diff canaryConfig.Difference) {
+	switch diff.Kind {
+	case canaryConfig.Added:
+		line := "+ " + diff.Subject
+		if diff.To != "" {
+			line += ": " + diff.To
+		}
+		fmt.Println(color.GreenString(line))
+	case canaryConfig.Removed:
+		line := "- " + diff.Subject
+		if diff.From != "" {
+			line += ": " + diff.From
+		}
+		fmt.Println(color.RedString(line))
+	case canaryConfig.Changed:
+		if len(diff.Fields) == 0 {
+			fmt.Println(color.YellowString("~ %s: %s → %s", diff.Subject, diff.From, diff.To))
+			return
+		}
+		fmt.Println(color.YellowString("~ %s", diff.Subject))
+		for _, field := range diff.Fields {
+			switch {
+			case field.From == "":
+				fmt.Println(color.GreenString("    + %s: %s", field.Path, field.To))
+			case field.To == "":
+				fmt.Println(color.RedString("    - %s: %s", field.Path, field.From))
+			default:
+				fmt.Printf("    %s: %s → %s\n", field.Path, color.RedString(field.From), color.GreenString(field.To))
+			}
+		}
+	}
+}
+
+func init() {
+	configCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffRemote, "remote", "", "name or id of the config in kayenta. defaults to the config's name")
+	diffCmd.Flags().StringVarP(&diffApplication, "application", "a", "", "application of the config in kayenta. defaults to the first application of the config")
+}
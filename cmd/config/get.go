@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/spf13/cobra"
+)
+
+var getOutput string
+
+// getCmd represents the get command
+var getCmd = &cobra.Command{
+	Use:   "get [config-id]",
+	Short: "print a canary config stored in kayenta",
+	Long: `Prints a canary config stored in kayenta. The yaml and json output can be saved
+and passed to analysis start --canary-config or config push.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd)
+		kc := newClient(globals)
+		cc, err := kc.GetCanaryConfig(cmd.Context(), args[0])
+		if err != nil {
+			exitcode.Exit(exitcode.FromError(err), "could not get canary config %s: %s", args[0], err.Error())
+		}
+
+		output, err := render(getOutput, cc, func() string { return tableFromConfig(cc) })
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, err.Error())
+		}
+		fmt.Print(string(output))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(getCmd)
+	getCmd.Flags().StringVarP(&getOutput, "output", "o", "yaml", "output format: table|json|yaml")
+}
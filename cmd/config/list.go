@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/spf13/cobra"
+)
+
+var listApplication, listOutput string
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the canary configs stored in kayenta",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd)
+		kc := newClient(globals)
+		configs, err := kc.GetCanaryConfigs(cmd.Context(), listApplication)
+		if err != nil {
+			exitcode.Exit(exitcode.FromError(err), "could not list canary configs: %s", err.Error())
+		}
+
+		output, err := render(listOutput, configs, func() string { return tableFromConfigs(configs) })
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, err.Error())
+		}
+		fmt.Print(string(output))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVarP(&listApplication, "application", "a", "", "only list the configs of this application")
+	listCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "output format: table|json|yaml")
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/armory-io/kayentactl/internal/canaryConfig"
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/options"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushApplication string
+	pushForce       bool
+)
+
+// pushCmd represents the push command
+var pushCmd = &cobra.Command{
+	Use:   "push [location]",
+	Short: "store a canary config in kayenta, creating or updating it",
+	Long: `Reads a canary config from a file or a file://, http:// or https:// URL, like
+analysis start --canary-config, and stores it in kayenta for the application.
+The application's config with the same name is updated, or a new config is
+created if there is none. The application is added to the applications of the
+config, and a config shared with other applications keeps them. The id of the
+stored config is printed.
+
+A config saved with config get carries the time it was last updated. If the
+config has been changed in kayenta since, push refuses to overwrite it unless
+--force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		globals, _ := options.Globals(cmd)
+		cc, err := canaryConfig.GetCanaryConfig(args[0])
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, "failed to load canary config: %s", err.Error())
+		}
+
+		application := pushApplication
+		if application == "" && len(cc.Applications) > 0 {
+			application = cc.Applications[0]
+		}
+		if application == "" {
+			exitcode.Exit(exitcode.ClientError, "the canary config has no applications, set one with --application")
+		}
+
+		if pushForce {
+			// only guard against changes made while pushing
+			cc.UpdatedTimestamp = 0
+		}
+
+		kc := newClient(globals)
+		id, err := kayenta.UpsertCanaryConfigs(cmd.Context(), kc, application, *cc)
+		var conflict kayenta.ConflictError
+		if errors.As(err, &conflict) {
+			exitcode.Exit(exitcode.Conflict, "could not push canary config: %s. fetch it again with config get %s and reapply your changes, or use --force to overwrite it",
+				err.Error(), conflict.ID)
+		}
+		if err != nil {
+			exitcode.Exit(exitcode.FromError(err), "could not push canary config: %s", err.Error())
+		}
+		// only the id goes to stdout, so that scripts can capture it
+		fmt.Fprintf(os.Stderr, "pushed canary config %s for application %s\n", cc.Name, application)
+		fmt.Println(id)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(pushCmd)
+	pushCmd.Flags().StringVarP(&pushApplication, "application", "a", "", "application the config belongs to. defaults to the first application of the config")
+	pushCmd.Flags().BoolVar(&pushForce, "force", false, "overwrite the config in kayenta even if it changed since the pushed copy was read")
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/internal/report"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/spf13/cobra"
+)
+
+var input, format, outputFile, templateFile string
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "render a report from an analysis result saved with -o json, without contacting kayenta",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := readResult(input)
+		if err != nil {
+			exitcode.Exit(exitcode.ClientError, "could not read analysis result: %s", err.Error())
+		}
+
+		out := io.Writer(os.Stdout)
+		if outputFile != "" {
+			f, err := os.Create(outputFile)
+			if err != nil {
+				exitcode.Exit(exitcode.ClientError, "could not create report file: %s", err.Error())
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := report.Report(result, format, out, report.WithTemplateFile(templateFile)); err != nil {
+			exitcode.Exit(exitcode.ClientError, "failed to generate result report: %s", err.Error())
+		}
+	},
+}
+
+// readResult reads a saved result from a file, or from stdin if path is -
+func readResult(path string) (kayenta.GetStandaloneCanaryAnalysisOutput, error) {
+	var result kayenta.GetStandaloneCanaryAnalysisOutput
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(b, &result)
+	return result, err
+}
+
+func init() {
+	reportCmd.AddCommand(renderCmd)
+	renderCmd.Flags().StringVarP(&input, "input", "i", "-", "analysis result saved with -o json. reads from stdin if -")
+	renderCmd.Flags().StringVar(&format, "format", "pretty", "report format: pretty|markdown|junit|html|template|json")
+	renderCmd.Flags().StringVar(&outputFile, "output-file", "", "write the report to a file instead of stdout")
+	renderCmd.Flags().StringVar(&templateFile, "template", "", "Go template file rendered by the template format")
+}
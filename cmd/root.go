@@ -16,13 +16,19 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/armory-io/kayentactl/internal/exitcode"
 	"github.com/armory-io/kayentactl/internal/options"
 
 	"github.com/armory-io/kayentactl/cmd/accounts"
 
 	"github.com/armory-io/kayentactl/cmd/analysis"
+	"github.com/armory-io/kayentactl/cmd/config"
+	"github.com/armory-io/kayentactl/cmd/report"
 	"github.com/armory-io/kayentactl/cmd/version"
 
 	"github.com/armory-io/kayentactl/internal/logger"
@@ -42,7 +48,10 @@ var rootCmd = &cobra.Command{
 	Short: "",
 	Long:  ``,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		globals, _ := options.Globals(cmd)
+		globals, err := options.Globals(cmd)
+		if err != nil {
+			return err
+		}
 		if err := initLogs(globals.Verbosity); err != nil {
 			return err
 		}
@@ -68,17 +77,23 @@ func initLogs(level string) error {
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The context handed to commands is cancelled when the process is interrupted or
+// terminated so that in-flight requests to kayenta are aborted.
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Error(err)
-		log.Fatal("Could not parse CLI arguments. Exiting.")
+		exitcode.Exit(exitcode.ClientError, "Could not parse CLI arguments. Exiting.")
 	}
 }
 
 func init() {
 	analysis.Configure(rootCmd)
 	accounts.Configure(rootCmd)
+	config.Configure(rootCmd)
+	report.Configure(rootCmd)
 	version.Configure(rootCmd)
 	// global options are added by an external pacakge so that they can be
 	// managed from a single source and used across all sub-commands. this
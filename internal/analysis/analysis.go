@@ -2,10 +2,13 @@ package analysis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/armory-io/kayentactl/internal/exitcode"
 	"github.com/armory-io/kayentactl/pkg/kayenta"
 )
 
@@ -43,34 +46,79 @@ func coordinates(scope string) (*scopeCoordinates, error) {
 	}, nil
 }
 
+// ExecutionRequestContext holds everything needed to build an execution request.
+// If Scopes is empty, a single scope named ScopeName (or default) is built from
+// ControlScope and ExperimentScope. StartTimeIso, EndTimeIso, ControlOffset and
+// ExtendedScopeParams apply to every scope, unless the scope sets them itself.
 type ExecutionRequestContext struct {
+	ScopeName                     string
 	ControlScope, ExperimentScope string
 	StartTimeIso, EndTimeIso      string
+	Scopes                        []kayenta.Scope
 
 	ControlOffset                              time.Duration
 	AnalysisIntervalMins, LifetimeDurationMins time.Duration
+	BeginAfterMins, LookbackMins               time.Duration
+
+	ExtendedScopeParams map[string]string
 
 	Thresholds kayenta.Threshold
 }
 
 func BuildExecutionRequest(ctx ExecutionRequestContext) (*kayenta.ExecutionRequest, error) {
-	scope, err := BuildScope(ctx.ControlScope, ctx.ExperimentScope)
-	if err != nil {
-		return nil, fmt.Errorf("could not construct execution request: %w", err)
+	scopes := ctx.Scopes
+	if len(scopes) == 0 {
+		scope, err := BuildScope(ctx.ControlScope, ctx.ExperimentScope)
+		if err != nil {
+			return nil, fmt.Errorf("could not construct execution request: %w", err)
+		}
+		if ctx.ScopeName != "" {
+			scope.ScopeName = ctx.ScopeName
+		}
+		scopes = []kayenta.Scope{*scope}
+	}
+
+	var requestScopes []kayenta.Scope
+	for _, scope := range scopes {
+		if scope.StartTimeIso == "" {
+			scope.StartTimeIso = ctx.StartTimeIso
+		}
+		if scope.EndTimeIso == "" {
+			scope.EndTimeIso = ctx.EndTimeIso
+		}
+		if scope.ControlOffsetInMinutes == 0 {
+			scope.ControlOffsetInMinutes = int(ctx.ControlOffset.Minutes())
+		}
+		scope.ExtendedScopeParams = mergeParams(ctx.ExtendedScopeParams, scope.ExtendedScopeParams)
+		requestScopes = append(requestScopes, scope)
 	}
 
-	scope.StartTimeIso = ctx.StartTimeIso
-	scope.EndTimeIso = ctx.EndTimeIso
-	scope.ControlOffsetInMinutes = int(ctx.ControlOffset.Minutes())
 	request := kayenta.ExecutionRequest{
-		Scopes:               []kayenta.Scope{*scope},
+		Scopes:               requestScopes,
 		AnalysisIntervalMins: int(ctx.AnalysisIntervalMins.Minutes()),
 		LifetimeDurationMins: int(ctx.LifetimeDurationMins.Minutes()),
+		BeginAfterMins:       int(ctx.BeginAfterMins.Minutes()),
+		LookbackMins:         int(ctx.LookbackMins.Minutes()),
 		Thresholds:           ctx.Thresholds,
 	}
 	return &request, nil
 }
 
+// mergeParams combines extended scope params, with values in override taking precedence
+func mergeParams(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+	merged := map[string]string{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
 func BuildScope(control, experiment string) (*kayenta.Scope, error) {
 	scope := kayenta.Scope{ScopeName: "default"}
 	{
@@ -98,24 +146,40 @@ type ProgressFunc func(res kayenta.GetStandaloneCanaryAnalysisOutput)
 //WaitForComplete this issues a call out to kayenta and then loops as it waits for it to finish. Likely we'll have to refactor the
 //call signature to inject dependencies, for example, a ticker perhaps should live otuside of this function
 // progressFunc is called on ever interval where the execution is not complete. if the execution is complete,
-// progressFunc will not be called and the function will terminate.
-func WaitForComplete(ctx context.Context, executionID string, client kayenta.StandaloneCanaryAnalysisAPI, ticker *time.Ticker, progressFunc ProgressFunc) error {
+// progressFunc will not be called and the function will terminate. the context is passed down to every request made
+// to kayenta, and if it is cancelled or its deadline is exceeded, ctx.Err() is returned.
+// polls that fail because kayenta is unreachable or has a server error are
+// retried on the next tick, so that a short outage or restart of kayenta doesn't
+// end a long wait. it gives up once maxFailures polls in a row have failed.
+func WaitForComplete(ctx context.Context, executionID string, client kayenta.StandaloneCanaryAnalysisAPI, ticker *time.Ticker, maxFailures int, progressFunc ProgressFunc) error {
 	done := make(chan bool, 1)
 	var err1 error
 
 	go func() {
+		failures := 0
 		for {
 			select {
 			case <-ctx.Done():
+				err1 = ctx.Err()
 				done <- true
 				return
 			case <-ticker.C:
-				res, err := client.GetStandaloneCanaryAnalysis(executionID)
-				if err != nil {
-					err1 = err
+				res, err := client.GetStandaloneCanaryAnalysis(ctx, executionID)
+				if err != nil && ctx.Err() != nil {
+					err1 = ctx.Err()
 					done <- true
 					return
 				}
+				if err != nil {
+					failures++
+					if !isTransient(err) || failures >= maxFailures {
+						err1 = err
+						done <- true
+						return
+					}
+					continue
+				}
+				failures = 0
 
 				if res.Complete {
 					done <- true
@@ -130,3 +194,13 @@ func WaitForComplete(ctx context.Context, executionID string, client kayenta.Sta
 	<-done
 	return err1
 }
+
+// isTransient reports whether a request that failed with err is worth
+// retrying, i.e. kayenta couldn't be reached or had a server error
+func isTransient(err error) bool {
+	var serverErr kayenta.ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.Code >= http.StatusInternalServerError
+	}
+	return exitcode.FromError(err) == exitcode.ServerUnreachable
+}
@@ -1,9 +1,13 @@
 package analysis
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -41,3 +45,85 @@ func TestConfigureExecRequest(t *testing.T) {
 
 	}
 }
+
+type stubAnalysisAPI struct {
+	kayenta.StandaloneCanaryAnalysisAPI
+}
+
+func (s *stubAnalysisAPI) GetStandaloneCanaryAnalysis(ctx context.Context, id string) (kayenta.GetStandaloneCanaryAnalysisOutput, error) {
+	return kayenta.GetStandaloneCanaryAnalysisOutput{}, ctx.Err()
+}
+
+func TestWaitForCompleteHonorsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := &stubAnalysisAPI{}
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	err := WaitForComplete(ctx, "some-id", client, ticker, 1, nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestWaitForCompleteAgainstFakeKayenta(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithScenario(kayentatest.Scenario{PollsPerStage: 2, Scores: []float64{80, 95}}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	started, err := client.StartStandaloneCanaryAnalysis(ctx, kayenta.StandaloneCanaryAnalysisInput{})
+	assert.Nil(t, err)
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	progressCalls := 0
+	err = WaitForComplete(ctx, started.CanaryAnalysisExecutionID, client, ticker, 1, func(res kayenta.GetStandaloneCanaryAnalysisOutput) {
+		progressCalls++
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 6, progressCalls)
+
+	result, _ := server.Execution(started.CanaryAnalysisExecutionID)
+	assert.True(t, result.IsSuccessful())
+	assert.Equal(t, []float64{80, 95}, result.CanaryAnalysisExecutionResult.CanaryScores)
+}
+
+func TestWaitForCompleteRetriesTransientErrors(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithScenario(kayentatest.Scenario{PollsPerStage: 1, Scores: []float64{95}}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	started, err := client.StartStandaloneCanaryAnalysis(ctx, kayenta.StandaloneCanaryAnalysisInput{})
+	assert.Nil(t, err)
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	server.InjectError("/standalone_canary_analysis/", 503, 4, "kayenta is restarting")
+	err = WaitForComplete(ctx, started.CanaryAnalysisExecutionID, client, ticker, 5, nil)
+	assert.Nil(t, err)
+
+	// giving up once too many polls in a row failed
+	started, err = client.StartStandaloneCanaryAnalysis(ctx, kayenta.StandaloneCanaryAnalysisInput{})
+	assert.Nil(t, err)
+	server.InjectError("/standalone_canary_analysis/", 502, 5, "bad gateway")
+	err = WaitForComplete(ctx, started.CanaryAnalysisExecutionID, client, ticker, 5, nil)
+	assert.Equal(t, kayenta.ServerError{Code: 502, Message: "bad gateway"}, err)
+}
+
+func TestWaitForCompleteStopsOnClientErrors(t *testing.T) {
+	server := kayentatest.NewServer()
+	defer server.Close()
+
+	client := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	err := WaitForComplete(context.Background(), "unknown-id", client, ticker, 5, nil)
+	var serverErr kayenta.ServerError
+	assert.True(t, errors.As(err, &serverErr))
+	assert.Equal(t, 404, serverErr.Code)
+}
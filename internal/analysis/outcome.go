@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"strconv"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// Outcome summarizes how an execution went
+type Outcome string
+
+const (
+	OutcomePass     Outcome = "passed"
+	OutcomeMarginal Outcome = "marginal"
+	OutcomeFail     Outcome = "failed"
+	OutcomeError    Outcome = "error"
+	OutcomeRunning  Outcome = "running"
+)
+
+// ResultOutcome determines the outcome of an execution. A failed execution
+// that produced a score is a marginal or failed canary depending on how its
+// final score compares to the thresholds it was started with. A failed
+// execution without a score, or whose score passed, failed for some other
+// reason and is an error.
+func ResultOutcome(result kayenta.GetStandaloneCanaryAnalysisOutput) Outcome {
+	if !result.Complete {
+		return OutcomeRunning
+	}
+	if result.IsSuccessful() {
+		return OutcomePass
+	}
+	if result.ExecutionStatus == "CANCELED" {
+		return OutcomeError
+	}
+
+	scores := result.CanaryAnalysisExecutionResult.CanaryScores
+	if len(scores) == 0 {
+		return OutcomeError
+	}
+	finalScore := scores[len(scores)-1]
+
+	thresholds := result.CanaryAnalysisExecutionRequest.Thresholds
+	if pass, err := strconv.ParseFloat(thresholds.Pass, 64); err == nil && finalScore >= pass {
+		return OutcomeError
+	}
+	if marginal, err := strconv.ParseFloat(thresholds.Marginal, 64); err == nil && finalScore >= marginal {
+		return OutcomeMarginal
+	}
+	return OutcomeFail
+}
+
+// ExitCode maps the outcome of an execution to one of the exit codes in the
+// exitcode package
+func ExitCode(result kayenta.GetStandaloneCanaryAnalysisOutput) int {
+	switch ResultOutcome(result) {
+	case OutcomePass:
+		return exitcode.Pass
+	case OutcomeMarginal:
+		return exitcode.Marginal
+	case OutcomeFail:
+		return exitcode.Fail
+	case OutcomeRunning:
+		return exitcode.Running
+	default:
+		return exitcode.ExecutionError
+	}
+}
@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/armory-io/kayentactl/internal/exitcode"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := map[string]struct {
+		scenario kayentatest.Scenario
+		cancel   bool
+		expected int
+	}{
+		"pass":            {scenario: kayentatest.Scenario{Scores: []float64{95}}, expected: exitcode.Pass},
+		"marginal":        {scenario: kayentatest.Scenario{Scores: []float64{95, 60}}, expected: exitcode.Marginal},
+		"fail":            {scenario: kayentatest.Scenario{Scores: []float64{95, 20}}, expected: exitcode.Fail},
+		"terminal":        {scenario: kayentatest.Scenario{Scores: []float64{95, 95}, Terminal: true}, expected: exitcode.ExecutionError},
+		"terminal, first": {scenario: kayentatest.Scenario{Scores: []float64{95}, Terminal: true}, expected: exitcode.ExecutionError},
+		"cancelled":       {scenario: kayentatest.DefaultScenario(), cancel: true, expected: exitcode.ExecutionError},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := kayentatest.NewServer(kayentatest.WithScenario(test.scenario))
+			defer server.Close()
+
+			ctx := context.Background()
+			client := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+			started, err := client.StartStandaloneCanaryAnalysis(ctx, kayenta.StandaloneCanaryAnalysisInput{
+				ExecutionRequest: kayenta.ExecutionRequest{Thresholds: kayenta.Threshold{Marginal: "50", Pass: "90"}},
+			})
+			assert.Nil(t, err)
+
+			running, err := client.GetStandaloneCanaryAnalysis(ctx, started.CanaryAnalysisExecutionID)
+			assert.Nil(t, err)
+			assert.Equal(t, exitcode.Running, ExitCode(running))
+
+			if test.cancel {
+				assert.Nil(t, client.CancelStandaloneCanaryAnalysis(ctx, started.CanaryAnalysisExecutionID))
+			}
+
+			ticker := time.NewTicker(time.Millisecond)
+			defer ticker.Stop()
+			assert.Nil(t, WaitForComplete(ctx, started.CanaryAnalysisExecutionID, client, ticker, 1, nil))
+
+			result, err := client.GetStandaloneCanaryAnalysis(ctx, started.CanaryAnalysisExecutionID)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, ExitCode(result))
+		})
+	}
+}
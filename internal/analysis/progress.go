@@ -22,10 +22,18 @@ type GraphicalProgressPrinter struct {
 }
 
 func NewDefaultGraphicalProgressPrinter() *GraphicalProgressPrinter {
+	return NewGraphicalProgressPrinter(os.Stdout)
+}
+
+// NewGraphicalProgressPrinter prints progress to out instead of stdout, e.g.
+// to keep stdout free for a report
+func NewGraphicalProgressPrinter(out io.Writer) *GraphicalProgressPrinter {
+	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+	s.Writer = out
 	return &GraphicalProgressPrinter{
-		s:         spinner.New(spinner.CharSets[9], 100*time.Millisecond),
+		s:         s,
 		numChecks: 0,
-		out:       os.Stdout,
+		out:       out,
 	}
 }
 
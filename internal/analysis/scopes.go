@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+const extendedScopeParamPrefix = "param."
+
+// ParseScope creates a kayenta.Scope from a comma-delimited list of key=value
+// pairs, for example:
+//
+//	name=us-east,control=prod/web-baseline,experiment=prod/web-canary,location=us-east-1,step=60,param.resourceType=k8s_container
+//
+// control and experiment use the same location/scope format as the --control
+// and --experiment flags. scope sets both the control and the experiment,
+// location sets both locations and step is either a number of seconds or a
+// duration. keys prefixed with param. become extended scope params.
+func ParseScope(definition string) (kayenta.Scope, error) {
+	scope := kayenta.Scope{ScopeName: "default"}
+	var control, experiment string
+	for _, part := range strings.Split(definition, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return kayenta.Scope{}, fmt.Errorf("invalid scope definition %q: %q is not in the format key=value", definition, part)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch {
+		case key == "name":
+			scope.ScopeName = value
+		case key == "scope":
+			control, experiment = value, value
+		case key == "control":
+			control = value
+		case key == "experiment":
+			experiment = value
+		case key == "location":
+			scope.ControlLocation, scope.ExperimentLocation = value, value
+		case key == "controlLocation":
+			scope.ControlLocation = value
+		case key == "experimentLocation":
+			scope.ExperimentLocation = value
+		case key == "step":
+			step, err := parseStep(value)
+			if err != nil {
+				return kayenta.Scope{}, fmt.Errorf("invalid scope definition %q: %w", definition, err)
+			}
+			scope.Step = step
+		case strings.HasPrefix(key, extendedScopeParamPrefix):
+			if scope.ExtendedScopeParams == nil {
+				scope.ExtendedScopeParams = map[string]string{}
+			}
+			scope.ExtendedScopeParams[strings.TrimPrefix(key, extendedScopeParamPrefix)] = value
+		default:
+			return kayenta.Scope{}, fmt.Errorf("invalid scope definition %q: unknown key %q", definition, key)
+		}
+	}
+
+	if control == "" || experiment == "" {
+		return kayenta.Scope{}, fmt.Errorf("invalid scope definition %q: a control and an experiment are required", definition)
+	}
+	built, err := BuildScope(control, experiment)
+	if err != nil {
+		return kayenta.Scope{}, err
+	}
+	// locations given as part of control or experiment win over location keys
+	scope.ControlScope, scope.ExperimentScope = built.ControlScope, built.ExperimentScope
+	if built.ControlLocation != "" {
+		scope.ControlLocation = built.ControlLocation
+	}
+	if built.ExperimentLocation != "" {
+		scope.ExperimentLocation = built.ExperimentLocation
+	}
+	return scope, nil
+}
+
+func parseStep(value string) (int, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return seconds, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("step %q is neither a number of seconds nor a duration", value)
+	}
+	return int(d.Seconds()), nil
+}
+
+// ScopeNames returns the distinct scope names used by the metrics of a canary config
+func ScopeNames(cc kayenta.CanaryConfig) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, metric := range cc.Metrics {
+		name := metric.ScopeName
+		if name == "" {
+			name = "default"
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateScopes checks that there is exactly one scope for every scope name
+// used by the canary config's metrics and no scopes that no metric uses
+func ValidateScopes(cc kayenta.CanaryConfig, scopes []kayenta.Scope) error {
+	defined := map[string]bool{}
+	for _, scope := range scopes {
+		if defined[scope.ScopeName] {
+			return fmt.Errorf("scope %q is defined more than once", scope.ScopeName)
+		}
+		defined[scope.ScopeName] = true
+	}
+
+	used := map[string]bool{}
+	for _, name := range ScopeNames(cc) {
+		used[name] = true
+		if !defined[name] {
+			return fmt.Errorf("canary config uses scope %q but no scope with that name was defined", name)
+		}
+	}
+	for _, scope := range scopes {
+		if !used[scope.ScopeName] {
+			return fmt.Errorf("scope %q is not used by any metric of the canary config", scope.ScopeName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScope(t *testing.T) {
+	scope, err := ParseScope("name=west,control=us-west-2/web-baseline,experiment=web-canary,location=us-west-1,step=1m,param.resourceType=k8s_container")
+	assert.Nil(t, err)
+	assert.Equal(t, kayenta.Scope{
+		ScopeName:           "west",
+		ControlScope:        "web-baseline",
+		ControlLocation:     "us-west-2",
+		ExperimentScope:     "web-canary",
+		ExperimentLocation:  "us-west-1",
+		Step:                60,
+		ExtendedScopeParams: map[string]string{"resourceType": "k8s_container"},
+	}, scope)
+
+	_, err = ParseScope("name=west,control=web")
+	assert.NotNil(t, err)
+	_, err = ParseScope("scope=web,colour=blue")
+	assert.NotNil(t, err)
+}
+
+func TestBuildMultiScopeExecutionRequest(t *testing.T) {
+	east, _ := ParseScope("name=east,scope=web,location=us-east-1,param.region=east")
+	west, _ := ParseScope("name=west,scope=web,location=us-west-1")
+
+	request, err := BuildExecutionRequest(ExecutionRequestContext{
+		Scopes:              []kayenta.Scope{east, west},
+		StartTimeIso:        "2021-01-01T00:00:00Z",
+		ControlOffset:       time.Hour,
+		ExtendedScopeParams: map[string]string{"region": "global", "project": "p"},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, request.Scopes, 2)
+	assert.Equal(t, "2021-01-01T00:00:00Z", request.Scopes[1].StartTimeIso)
+	assert.Equal(t, 60, request.Scopes[1].ControlOffsetInMinutes)
+	assert.Equal(t, map[string]string{"region": "east", "project": "p"}, request.Scopes[0].ExtendedScopeParams)
+	assert.Equal(t, map[string]string{"region": "global", "project": "p"}, request.Scopes[1].ExtendedScopeParams)
+}
+
+func TestValidateScopes(t *testing.T) {
+	cc := kayenta.CanaryConfig{Metrics: []kayenta.Metric{{ScopeName: "east"}, {ScopeName: "west"}, {ScopeName: "east"}}}
+	assert.Equal(t, []string{"east", "west"}, ScopeNames(cc))
+
+	east := kayenta.Scope{ScopeName: "east"}
+	west := kayenta.Scope{ScopeName: "west"}
+	assert.Nil(t, ValidateScopes(cc, []kayenta.Scope{east, west}))
+	assert.EqualError(t, ValidateScopes(cc, []kayenta.Scope{east}), `canary config uses scope "west" but no scope with that name was defined`)
+	assert.EqualError(t, ValidateScopes(cc, []kayenta.Scope{east, west, {ScopeName: "north"}}), `scope "north" is not used by any metric of the canary config`)
+	assert.EqualError(t, ValidateScopes(cc, []kayenta.Scope{east, west, east}), `scope "east" is defined more than once`)
+}
@@ -0,0 +1,160 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/armory-io/kayentactl/internal/canaryConfig"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/ghodss/yaml"
+)
+
+// RunSpec describes an analysis run so that it can be kept in a YAML or JSON
+// file next to the canary config instead of being passed as flags. Each field
+// corresponds to a flag of `analysis start`.
+type RunSpec struct {
+	CanaryConfig string `json:"canaryConfig,omitempty"`
+
+	Scope               string            `json:"scope,omitempty"`
+	Control             string            `json:"control,omitempty"`
+	Experiment          string            `json:"experiment,omitempty"`
+	Scopes              []kayenta.Scope   `json:"scopes,omitempty"`
+	StartTimeIso        string            `json:"startTimeIso,omitempty"`
+	EndTimeIso          string            `json:"endTimeIso,omitempty"`
+	Thresholds          SpecThresholds    `json:"thresholds"`
+	ExtendedScopeParams map[string]string `json:"extendedScopeParams,omitempty"`
+
+	MetricsAccount       string `json:"metricsAccount,omitempty"`
+	StorageAccount       string `json:"storageAccount,omitempty"`
+	ConfigurationAccount string `json:"configurationAccount,omitempty"`
+
+	User                      string `json:"user,omitempty"`
+	Application               string `json:"application,omitempty"`
+	ParentPipelineExecutionID string `json:"parentPipelineExecutionId,omitempty"`
+
+	AnalysisInterval Duration `json:"analysisInterval,omitempty"`
+	LifetimeDuration Duration `json:"lifetimeDuration,omitempty"`
+	BeginAfter       Duration `json:"beginAfter,omitempty"`
+	Lookback         Duration `json:"lookback,omitempty"`
+	ControlOffset    Duration `json:"controlOffset,omitempty"`
+	PollInterval     Duration `json:"pollInterval,omitempty"`
+	Timeout          Duration `json:"timeout,omitempty"`
+	NoWait           bool     `json:"noWait,omitempty"`
+
+	Output OutputSpec `json:"output"`
+}
+
+// SpecThresholds accepts thresholds written either as numbers or strings
+type SpecThresholds struct {
+	Marginal json.Number `json:"marginal,omitempty"`
+	Pass     json.Number `json:"pass,omitempty"`
+}
+
+func (t SpecThresholds) Threshold() kayenta.Threshold {
+	return kayenta.Threshold{Marginal: t.Marginal.String(), Pass: t.Pass.String()}
+}
+
+// OutputFormats are the report formats an analysis result can be written in
+var OutputFormats = []string{"pretty", "json", "junit", "markdown", "html", "template"}
+
+type OutputSpec struct {
+	// Format is the report format, e.g. pretty or json
+	Format string `json:"format,omitempty"`
+	// File is where the report is written. The report is written to stdout if empty.
+	File string `json:"file,omitempty"`
+	// Template is the Go template file rendered by the template format
+	Template string `json:"template,omitempty"`
+}
+
+// Duration is a time.Duration written as a string like 5m or 1h30m
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("durations must be strings like 5m or 1h30m: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadRunSpec reads a YAML or JSON run spec from path into spec. Fields that
+// are not in the file keep the value they already had in spec, which lets
+// callers pre-populate spec with defaults. Relative canaryConfig and output
+// template paths are resolved against the directory of the run spec, unless
+// canaryConfig is the id of a config stored in kayenta.
+func LoadRunSpec(path string, spec *RunSpec) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read run spec: %w", err)
+	}
+
+	var fromFile RunSpec
+	if err := yaml.Unmarshal(b, &fromFile); err != nil {
+		return fmt.Errorf("failed to deserialize run spec: %w", err)
+	}
+	if err := yaml.Unmarshal(b, spec); err != nil {
+		return fmt.Errorf("failed to deserialize run spec: %w", err)
+	}
+
+	location := fromFile.CanaryConfig
+	if location != "" && !strings.Contains(location, "://") && !filepath.IsAbs(location) {
+		// ids of configs stored in kayenta are kept as-is
+		relative := filepath.Join(filepath.Dir(path), location)
+		if _, err := os.Stat(relative); err == nil || !canaryConfig.IsConfigID(location) {
+			spec.CanaryConfig = relative
+		}
+	}
+	if tmpl := fromFile.Output.Template; tmpl != "" && !filepath.IsAbs(tmpl) {
+		spec.Output.Template = filepath.Join(filepath.Dir(path), tmpl)
+	}
+	return nil
+}
+
+// Validate checks for settings in the spec that conflict with each other
+func (s RunSpec) Validate() error {
+	if s.CanaryConfig == "" {
+		return errors.New("a canary config is required")
+	}
+	if len(s.Scopes) > 0 && (s.Scope != "" || s.Control != "" || s.Experiment != "") {
+		return errors.New("scopes cannot be combined with scope, control or experiment")
+	}
+	return s.Output.Validate()
+}
+
+// Validate checks that the report can be written, so that a mistake doesn't
+// only show once the analysis has completed
+func (o OutputSpec) Validate() error {
+	known := false
+	for _, format := range OutputFormats {
+		known = known || format == o.Format
+	}
+	if !known {
+		return fmt.Errorf("unknown output format %q, use one of %s", o.Format, strings.Join(OutputFormats, ", "))
+	}
+	if o.Format != "template" {
+		return nil
+	}
+	if o.Template == "" {
+		return errors.New("the template output format requires a template file")
+	}
+	f, err := os.Open(o.Template)
+	if err != nil {
+		return fmt.Errorf("could not read the template: %w", err)
+	}
+	return f.Close()
+}
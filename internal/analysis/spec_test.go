@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRunSpec = `
+canaryConfig: canary.yml
+scopes:
+  - scopeName: default
+    controlScope: web-baseline
+    experimentScope: web-canary
+    controlLocation: us-east-1
+    experimentLocation: us-east-1
+thresholds:
+  marginal: 60
+  pass: "80"
+metricsAccount: prometheus
+lifetimeDuration: 30m
+output:
+  format: template
+  template: report.tmpl
+`
+
+func TestLoadRunSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runspec")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.yaml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(testRunSpec), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "report.tmpl"), []byte("{{ .Outcome }}"), 0644))
+
+	spec := RunSpec{
+		LifetimeDuration: Duration(5 * time.Minute),
+		Timeout:          Duration(time.Hour),
+		StorageAccount:   "default-storage",
+	}
+	assert.Nil(t, LoadRunSpec(path, &spec))
+
+	assert.Equal(t, filepath.Join(dir, "canary.yml"), spec.CanaryConfig)
+	assert.Equal(t, kayenta.Threshold{Marginal: "60", Pass: "80"}, spec.Thresholds.Threshold())
+	assert.Equal(t, Duration(30*time.Minute), spec.LifetimeDuration)
+	assert.Equal(t, Duration(time.Hour), spec.Timeout)
+	assert.Equal(t, "default-storage", spec.StorageAccount)
+	assert.Equal(t, "prometheus", spec.MetricsAccount)
+	assert.Equal(t, "template", spec.Output.Format)
+	assert.Equal(t, filepath.Join(dir, "report.tmpl"), spec.Output.Template)
+	assert.Equal(t, "us-east-1", spec.Scopes[0].ControlLocation)
+	assert.Nil(t, spec.Validate())
+
+	spec.Scope = "web"
+	assert.NotNil(t, spec.Validate())
+}
+
+func TestLoadRunSpecStoredCanaryConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runspec")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.yaml")
+
+	for _, location := range []string{"kayenta://web/latency", "0c5a9e3e-4c1f-4b8a-9f0e-2c0d5e6f7a8b"} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte("canaryConfig: "+location), 0644))
+		var spec RunSpec
+		assert.Nil(t, LoadRunSpec(path, &spec))
+		assert.Equal(t, location, spec.CanaryConfig)
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runspec")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	tmpl := filepath.Join(dir, "report.tmpl")
+	assert.Nil(t, ioutil.WriteFile(tmpl, []byte("{{ .Outcome }}"), 0644))
+
+	tests := []struct {
+		name    string
+		output  OutputSpec
+		wantErr bool
+	}{
+		{name: "pretty", output: OutputSpec{Format: "pretty"}},
+		{name: "json to a file", output: OutputSpec{Format: "json", File: "result.json"}},
+		{name: "template", output: OutputSpec{Format: "template", Template: tmpl}},
+		{name: "unknown format", output: OutputSpec{Format: "xml"}, wantErr: true},
+		{name: "no format", output: OutputSpec{}, wantErr: true},
+		{name: "template without file", output: OutputSpec{Format: "template"}, wantErr: true},
+		{name: "missing template", output: OutputSpec{Format: "template", Template: filepath.Join(dir, "missing.tmpl")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := RunSpec{CanaryConfig: "canary.yml", Output: tt.output}
+			err := spec.Validate()
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
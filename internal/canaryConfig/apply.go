@@ -0,0 +1,282 @@
+package canaryConfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// LocalConfig is a canary config read from a file
+type LocalConfig struct {
+	Path   string
+	Config kayenta.CanaryConfig
+}
+
+// LoadDir reads every .json, .yml and .yaml file in dir as a canary config.
+// Subdirectories are not read.
+func LoadDir(dir string) ([]LocalConfig, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var configs []LocalConfig
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json", ".yml", ".yaml":
+		default:
+			continue
+		}
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		cc, err := GetCanaryConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		configs = append(configs, LocalConfig{Path: path, Config: *cc})
+	}
+	return configs, nil
+}
+
+// Action is what applying a Change does to a config in kayenta
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionDelete    Action = "delete"
+	ActionUnchanged Action = "unchanged"
+)
+
+// Change is one step of a Plan
+type Change struct {
+	Action            Action
+	Application, Name string
+	// ID is the id of the config in kayenta, empty for configs to be created
+	ID string
+	// Path is the file the config was read from, empty for configs to be deleted
+	Path   string
+	Config kayenta.CanaryConfig
+	// UpdatedTimestamp is the updatedTimestamp of the config in kayenta when
+	// the plan was made. an update fails if the config changed since
+	UpdatedTimestamp int64
+	// Note explains a change that isn't obvious from its action, like pruning
+	// a config shared with other applications
+	Note string
+}
+
+// Plan lists the changes that make kayenta match a directory of configs
+type Plan struct {
+	Changes []Change
+}
+
+// Count is the number of changes with the given action
+func (p Plan) Count(action Action) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges reports whether applying the plan would change anything
+func (p Plan) HasChanges() bool {
+	return p.Count(ActionUnchanged) < len(p.Changes)
+}
+
+// ApplyOptions controls how a directory of configs is reconciled with kayenta
+type ApplyOptions struct {
+	// Application is the application every config is applied to, added to
+	// the applications of configs that don't list it. if empty, the first
+	// application of each config is used
+	Application string
+	// Prune deletes configs of Application that aren't in the directory.
+	// configs shared with other applications are only removed from Application
+	Prune bool
+}
+
+// PlanApply compares configs read from files with the configs in kayenta.
+// Configs are matched by application and name.
+func PlanApply(ctx context.Context, api kayenta.CanaryConfigAPI, local []LocalConfig, opts ApplyOptions) (Plan, error) {
+	if opts.Prune && opts.Application == "" {
+		return Plan{}, errors.New("pruning requires an application, so that configs of other applications are left alone")
+	}
+
+	var changes []Change
+	paths := map[string]string{}
+	applications := map[string]bool{}
+	for _, lc := range local {
+		application := opts.Application
+		if application == "" && len(lc.Config.Applications) > 0 {
+			application = lc.Config.Applications[0]
+		}
+		if application == "" {
+			return Plan{}, fmt.Errorf("%s: the canary config has no applications", lc.Path)
+		}
+		key := application + "/" + lc.Config.Name
+		if other, ok := paths[key]; ok {
+			return Plan{}, fmt.Errorf("%s and %s both define canary config %s of application %s", other, lc.Path, lc.Config.Name, application)
+		}
+		paths[key] = lc.Path
+		applications[application] = true
+
+		// configs shared by several applications keep all of them
+		cc := lc.Config
+		if !containsApplication(cc, application) {
+			cc.Applications = append(append([]string(nil), cc.Applications...), application)
+		}
+		changes = append(changes, Change{Application: application, Name: cc.Name, Path: lc.Path, Config: cc})
+	}
+	if opts.Prune {
+		applications[opts.Application] = true
+	}
+
+	remote := map[string]kayenta.CanaryConfig{}
+	for application := range applications {
+		configs, err := api.GetCanaryConfigs(ctx, application)
+		if err != nil {
+			return Plan{}, err
+		}
+		for _, cc := range configs {
+			if !containsApplication(cc, application) {
+				continue
+			}
+			key := application + "/" + cc.Name
+			if other, ok := remote[key]; ok {
+				return Plan{}, fmt.Errorf("application %s has more than one canary config named %s: %s, %s", application, cc.Name, other.Id, cc.Id)
+			}
+			remote[key] = cc
+		}
+	}
+
+	for i, change := range changes {
+		key := change.Application + "/" + change.Name
+		existing, ok := remote[key]
+		if !ok {
+			changes[i].Action = ActionCreate
+			continue
+		}
+		delete(remote, key)
+
+		// kayenta only lists summaries of configs
+		current, err := api.GetCanaryConfig(ctx, existing.Id)
+		if err != nil {
+			return Plan{}, err
+		}
+		changes[i].ID = existing.Id
+		changes[i].UpdatedTimestamp = current.UpdatedTimestamp
+		equal, err := Equal(change.Config, current)
+		if err != nil {
+			return Plan{}, err
+		}
+		if equal {
+			changes[i].Action = ActionUnchanged
+		} else {
+			changes[i].Action = ActionUpdate
+		}
+	}
+
+	if opts.Prune {
+		for _, cc := range remote {
+			if !containsApplication(cc, opts.Application) {
+				continue
+			}
+			if len(cc.Applications) == 1 {
+				changes = append(changes, Change{Action: ActionDelete, Application: opts.Application, Name: cc.Name, ID: cc.Id, Config: cc})
+				continue
+			}
+
+			// a config shared with other applications is only detached from
+			// this one
+			current, err := api.GetCanaryConfig(ctx, cc.Id)
+			if err != nil {
+				return Plan{}, err
+			}
+			var others []string
+			for _, app := range current.Applications {
+				if app != opts.Application {
+					others = append(others, app)
+				}
+			}
+			current.Applications = others
+			changes = append(changes, Change{
+				Action:           ActionUpdate,
+				Application:      opts.Application,
+				Name:             cc.Name,
+				ID:               cc.Id,
+				Config:           current,
+				UpdatedTimestamp: current.UpdatedTimestamp,
+				Note:             fmt.Sprintf("remove application %s, keep it for %s", opts.Application, strings.Join(others, ", ")),
+			})
+		}
+	}
+
+	// deletes go last, so that nothing is removed if creating or updating fails
+	rank := map[Action]int{ActionUnchanged: 0, ActionCreate: 1, ActionUpdate: 1, ActionDelete: 2}
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if rank[a.Action] != rank[b.Action] {
+			return rank[a.Action] < rank[b.Action]
+		}
+		if a.Application != b.Application {
+			return a.Application < b.Application
+		}
+		return a.Name < b.Name
+	})
+	return Plan{Changes: changes}, nil
+}
+
+// Apply makes the changes of the plan in order, calling applied after each
+// one. It stops at the first change that fails. Updates fail with a
+// kayenta.ConflictError if the config changed in kayenta after the plan was made.
+func Apply(ctx context.Context, api kayenta.CanaryConfigAPI, plan Plan, applied func(change Change, id string)) error {
+	for _, change := range plan.Changes {
+		var (
+			id  = change.ID
+			err error
+		)
+		switch change.Action {
+		case ActionCreate:
+			// files saved with config get carry the id and timestamps of
+			// the config they were read from
+			cc := change.Config
+			cc.Id = ""
+			cc.CreatedTimestamp, cc.CreatedTimestampIso = 0, ""
+			cc.UpdatedTimestamp, cc.UpdatedTimestampIso = 0, ""
+			id, err = api.CreateCanaryConfig(ctx, cc)
+		case ActionUpdate:
+			cc := change.Config
+			cc.Id = change.ID
+			_, err = kayenta.UpdateCanaryConfigIfUnchanged(ctx, api, cc, change.UpdatedTimestamp)
+		case ActionDelete:
+			err = api.DeleteCanaryConfig(ctx, change.ID)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not %s canary config %s of application %s: %w", change.Action, change.Name, change.Application, err)
+		}
+		if applied != nil {
+			applied(change, id)
+		}
+	}
+	return nil
+}
+
+func containsApplication(cc kayenta.CanaryConfig, application string) bool {
+	for _, app := range cc.Applications {
+		if app == application {
+			return true
+		}
+	}
+	return false
+}
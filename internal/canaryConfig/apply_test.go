@@ -0,0 +1,212 @@
+package canaryConfig
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	local := storedConfig("latency", "web")
+	remote := local
+	remote.Id = "some-id"
+	remote.UpdatedTimestamp = 1608472800000
+	remote.Templates = map[string]string{}
+	equal, err := Equal(local, remote)
+	assert.Nil(t, err)
+	assert.True(t, equal)
+
+	remote.Metrics = append(remote.Metrics, remote.Metrics[0])
+	equal, err = Equal(local, remote)
+	assert.Nil(t, err)
+	assert.False(t, equal)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "canaryconfigs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	config := `{"name": "latency", "metrics": [{"name": "errors", "query": {"type": "prometheus", "customInlineTemplate": "PromQL:sum(errors)"}}]}`
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "latency.json"), []byte(config), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# configs"), 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "nested.yml"), 0755))
+
+	configs, err := LoadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, configs, 1)
+	assert.Equal(t, filepath.Join(dir, "latency.json"), configs[0].Path)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "invalid.yml"), []byte("name: invalid"), 0644))
+	_, err = LoadDir(dir)
+	assert.Contains(t, err.Error(), "invalid.yml")
+}
+
+func TestPlanApply(t *testing.T) {
+	changed := storedConfig("errors", "web")
+	changed.Description = "before"
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(
+		storedConfig("latency", "web"),
+		changed,
+		storedConfig("old", "web"),
+		storedConfig("old", "api"),
+	))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	errorsConfig := storedConfig("errors", "")
+	errorsConfig.Description = "after"
+	local := []LocalConfig{
+		{Path: "latency.yml", Config: storedConfig("latency", "")},
+		{Path: "errors.yml", Config: errorsConfig},
+		{Path: "new.yml", Config: storedConfig("new", "")},
+	}
+
+	plan, err := PlanApply(ctx, c, local, ApplyOptions{Application: "web", Prune: true})
+	assert.Nil(t, err)
+	var summary []string
+	for _, change := range plan.Changes {
+		summary = append(summary, string(change.Action)+" "+change.Application+"/"+change.Name)
+	}
+	assert.Equal(t, []string{"unchanged web/latency", "update web/errors", "create web/new", "delete web/old"}, summary)
+	assert.True(t, plan.HasChanges())
+
+	var applied []string
+	err = Apply(ctx, c, plan, func(change Change, id string) {
+		applied = append(applied, string(change.Action)+" "+id)
+	})
+	assert.Nil(t, err)
+	assert.Len(t, applied, 3)
+	assert.Len(t, server.CanaryConfigs(), 4)
+
+	plan, err = PlanApply(ctx, c, local, ApplyOptions{Application: "web", Prune: true})
+	assert.Nil(t, err)
+	assert.False(t, plan.HasChanges())
+	assert.Equal(t, 3, plan.Count(ActionUnchanged))
+}
+
+func TestPlanApplySharedConfig(t *testing.T) {
+	shared := storedConfig("latency", "web")
+	shared.Applications = []string{"web", "api"}
+	stored := shared
+	stored.Applications = []string{"api", "web"}
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(stored))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	for _, opts := range []ApplyOptions{{}, {Application: "api"}} {
+		plan, err := PlanApply(ctx, c, []LocalConfig{{Path: "latency.yml", Config: shared}}, opts)
+		assert.Nil(t, err)
+		assert.False(t, plan.HasChanges())
+	}
+
+	// --application is added to the config's own applications
+	plan, err := PlanApply(ctx, c, []LocalConfig{{Path: "latency.yml", Config: shared}}, ApplyOptions{Application: "batch"})
+	assert.Nil(t, err)
+	assert.Equal(t, ActionCreate, plan.Changes[0].Action)
+	assert.Equal(t, []string{"web", "api", "batch"}, plan.Changes[0].Config.Applications)
+}
+
+func TestPlanApplyPruneSharedConfig(t *testing.T) {
+	shared := storedConfig("shared", "web")
+	shared.Applications = []string{"web", "api"}
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(shared, storedConfig("old", "web")))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	plan, err := PlanApply(ctx, c, nil, ApplyOptions{Application: "web", Prune: true})
+	assert.Nil(t, err)
+	assert.Len(t, plan.Changes, 2)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	assert.Equal(t, "shared", plan.Changes[0].Name)
+	assert.Equal(t, []string{"api"}, plan.Changes[0].Config.Applications)
+	assert.Equal(t, ActionDelete, plan.Changes[1].Action)
+	assert.Equal(t, "old", plan.Changes[1].Name)
+
+	assert.Nil(t, Apply(ctx, c, plan, nil))
+	configs := server.CanaryConfigs()
+	assert.Len(t, configs, 1)
+	assert.Equal(t, "shared", configs[0].Name)
+	assert.Equal(t, []string{"api"}, configs[0].Applications)
+}
+
+// recordingAPI records the configs it is asked to create
+type recordingAPI struct {
+	kayenta.CanaryConfigAPI
+	created []kayenta.CanaryConfig
+}
+
+func (r *recordingAPI) CreateCanaryConfig(ctx context.Context, cc kayenta.CanaryConfig) (string, error) {
+	r.created = append(r.created, cc)
+	return r.CanaryConfigAPI.CreateCanaryConfig(ctx, cc)
+}
+
+func TestApplyCreateClearsID(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(storedConfig("latency", "api")))
+	defer server.Close()
+	ctx := context.Background()
+	c := &recordingAPI{CanaryConfigAPI: kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))}
+
+	// a config saved from another application with config get
+	saved := storedConfig("latency", "web")
+	saved.Id = server.CanaryConfigs()[0].Id
+	saved.UpdatedTimestamp, saved.UpdatedTimestampIso = 1608472800000, "2020-12-20T14:00:00.000Z"
+
+	plan, err := PlanApply(ctx, c, []LocalConfig{{Path: "latency.yml", Config: saved}}, ApplyOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, Apply(ctx, c, plan, nil))
+	assert.Len(t, c.created, 1)
+	assert.Equal(t, "", c.created[0].Id)
+	assert.Zero(t, c.created[0].UpdatedTimestamp)
+	assert.Equal(t, "", c.created[0].UpdatedTimestampIso)
+	assert.Len(t, server.CanaryConfigs(), 2)
+}
+
+func TestPlanApplyErrors(t *testing.T) {
+	server := kayentatest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	_, err := PlanApply(ctx, c, nil, ApplyOptions{Prune: true})
+	assert.NotNil(t, err)
+
+	_, err = PlanApply(ctx, c, []LocalConfig{{Path: "a.yml", Config: storedConfig("latency", "")}}, ApplyOptions{})
+	assert.EqualError(t, err, "a.yml: the canary config has no applications")
+
+	_, err = PlanApply(ctx, c, []LocalConfig{
+		{Path: "a.yml", Config: storedConfig("latency", "web")},
+		{Path: "b.yml", Config: storedConfig("latency", "web")},
+	}, ApplyOptions{})
+	assert.EqualError(t, err, "a.yml and b.yml both define canary config latency of application web")
+}
+
+func TestApplyConflict(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(storedConfig("latency", "web")))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	cc := storedConfig("latency", "web")
+	cc.Description = "mine"
+	plan, err := PlanApply(ctx, c, []LocalConfig{{Path: "latency.yml", Config: cc}}, ApplyOptions{})
+	assert.Nil(t, err)
+
+	// someone else changes the config after the plan was made
+	_, err = kayenta.UpsertCanaryConfigs(ctx, c, "web", storedConfig("latency", "web"))
+	assert.Nil(t, err)
+
+	err = Apply(ctx, c, plan, nil)
+	var conflict kayenta.ConflictError
+	assert.True(t, errors.As(err, &conflict))
+}
@@ -0,0 +1,222 @@
+package canaryConfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// ChangeKind is how a part of a canary config differs between two copies
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Difference is one semantic difference between two canary configs, e.g. a
+// metric that was added or a group weight that changed
+type Difference struct {
+	Kind ChangeKind
+	// Subject is what differs, e.g. metric "cpu", group weight "CPU" or description
+	Subject string
+	// Fields lists the changed fields of a changed subject that has fields,
+	// like the query of a metric
+	Fields []FieldDifference
+	// From and To are the old and new values of a changed subject without fields
+	From, To string
+}
+
+// FieldDifference is a changed field, e.g. query.metricName. From or To is
+// empty if the field was added or removed
+type FieldDifference struct {
+	Path     string
+	From, To string
+}
+
+// Diff lists how to differs from from, once both are normalized. Metrics are
+// matched by name and group weights by group, so reordering them is no change.
+func Diff(from, to kayenta.CanaryConfig) ([]Difference, error) {
+	from, to = Normalize(from), Normalize(to)
+	var diffs []Difference
+
+	scalar := func(subject, a, b string) {
+		if a != b {
+			diffs = append(diffs, Difference{Kind: Changed, Subject: subject, From: fmt.Sprintf("%q", a), To: fmt.Sprintf("%q", b)})
+		}
+	}
+	scalar("name", from.Name, to.Name)
+	scalar("description", from.Description, to.Description)
+	scalar("configVersion", from.ConfigVersion, to.ConfigVersion)
+	scalar("applications", strings.Join(from.Applications, ", "), strings.Join(to.Applications, ", "))
+
+	judge, err := diffFields(from.Judge, to.Judge)
+	if err != nil {
+		return nil, err
+	}
+	if len(judge) > 0 {
+		diffs = append(diffs, Difference{Kind: Changed, Subject: "judge", Fields: judge})
+	}
+
+	templates, err := diffFields(from.Templates, to.Templates)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) > 0 {
+		diffs = append(diffs, Difference{Kind: Changed, Subject: "templates", Fields: templates})
+	}
+
+	// fields kayentactl doesn't model, like classifier.scoreThresholds
+	other, err := diffFields(from.Other, to.Other)
+	if err != nil {
+		return nil, err
+	}
+	if len(other) > 0 {
+		diffs = append(diffs, Difference{Kind: Changed, Subject: "other fields", Fields: other})
+	}
+	classifier, err := diffFields(from.Classifier.Other, to.Classifier.Other)
+	if err != nil {
+		return nil, err
+	}
+	if len(classifier) > 0 {
+		diffs = append(diffs, Difference{Kind: Changed, Subject: "classifier", Fields: classifier})
+	}
+
+	metrics, err := diffMetrics(from.Metrics, to.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, metrics...)
+
+	return append(diffs, diffWeights(from.Classifier.GroupWeights, to.Classifier.GroupWeights)...), nil
+}
+
+func diffMetrics(from, to []kayenta.Metric) ([]Difference, error) {
+	byName := func(metrics []kayenta.Metric) (map[string]kayenta.Metric, []string) {
+		m := map[string]kayenta.Metric{}
+		var names []string
+		for _, metric := range metrics {
+			if _, ok := m[metric.Name]; !ok {
+				names = append(names, metric.Name)
+			}
+			m[metric.Name] = metric
+		}
+		return m, names
+	}
+	fromMetrics, fromNames := byName(from)
+	toMetrics, toNames := byName(to)
+
+	var diffs []Difference
+	for _, name := range fromNames {
+		subject := fmt.Sprintf("metric %q", name)
+		other, ok := toMetrics[name]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: Removed, Subject: subject})
+			continue
+		}
+		fields, err := diffFields(fromMetrics[name], other)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			diffs = append(diffs, Difference{Kind: Changed, Subject: subject, Fields: fields})
+		}
+	}
+	for _, name := range toNames {
+		if _, ok := fromMetrics[name]; !ok {
+			diffs = append(diffs, Difference{Kind: Added, Subject: fmt.Sprintf("metric %q", name)})
+		}
+	}
+	return diffs, nil
+}
+
+func diffWeights(from, to map[string]float64) []Difference {
+	groups := map[string]bool{}
+	for group := range from {
+		groups[group] = true
+	}
+	for group := range to {
+		groups[group] = true
+	}
+	var sorted []string
+	for group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Strings(sorted)
+
+	var diffs []Difference
+	for _, group := range sorted {
+		subject := fmt.Sprintf("group weight %q", group)
+		a, inFrom := from[group]
+		b, inTo := to[group]
+		switch {
+		case !inTo:
+			diffs = append(diffs, Difference{Kind: Removed, Subject: subject, From: fmt.Sprint(a)})
+		case !inFrom:
+			diffs = append(diffs, Difference{Kind: Added, Subject: subject, To: fmt.Sprint(b)})
+		case a != b:
+			diffs = append(diffs, Difference{Kind: Changed, Subject: subject, From: fmt.Sprint(a), To: fmt.Sprint(b)})
+		}
+	}
+	return diffs
+}
+
+// diffFields compares the normalized JSON of a and b field by field
+func diffFields(a, b interface{}) ([]FieldDifference, error) {
+	na, err := normalizedValue(a)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := normalizedValue(b)
+	if err != nil {
+		return nil, err
+	}
+	fa, fb := map[string]string{}, map[string]string{}
+	flatten("", na, fa)
+	flatten("", nb, fb)
+
+	paths := map[string]bool{}
+	for path := range fa {
+		paths[path] = true
+	}
+	for path := range fb {
+		paths[path] = true
+	}
+	var diffs []FieldDifference
+	for path := range paths {
+		if fa[path] != fb[path] {
+			diffs = append(diffs, FieldDifference{Path: path, From: fa[path], To: fb[path]})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+// flatten maps the path of every leaf of a generic JSON value to the leaf's
+// JSON, e.g. query.metricName to "avg:cpu". lists are compared as a whole
+func flatten(prefix string, v interface{}, out map[string]string) {
+	if m, ok := v.(map[string]interface{}); ok {
+		for k, field := range m {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flatten(path, field, out)
+		}
+		return
+	}
+	if v == nil {
+		return
+	}
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	// keep queries readable, e.g. status >= '500'
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+	out[prefix] = strings.TrimSuffix(b.String(), "\n")
+}
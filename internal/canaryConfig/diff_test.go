@@ -0,0 +1,82 @@
+package canaryConfig
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	remote := storedConfig("latency", "web")
+	remote.Id = "some-id"
+	remote.UpdatedTimestamp = 1608472800000
+	remote.Metrics = append(remote.Metrics, kayenta.Metric{
+		Name:  "io",
+		Query: kayenta.MetricQuery{Query: &kayenta.PrometheusQuery{CustomInlineTemplate: "PromQL:sum(io)"}},
+	})
+	remote.Classifier.GroupWeights = map[string]float64{"CPU": 35, "IO": 65}
+
+	local := storedConfig("latency", "web")
+	local.Description = "latency of the web tier"
+	local.Metrics[0].Query = kayenta.MetricQuery{Query: &kayenta.PrometheusQuery{CustomInlineTemplate: "PromQL:sum(errors_total)"}}
+	local.Metrics[0].AnalysisConfigurations.Canary = &kayenta.CanaryAnalysisConfiguration{Critical: true}
+	local.Metrics = append([]kayenta.Metric{{
+		Name:  "memory",
+		Query: kayenta.MetricQuery{Query: &kayenta.PrometheusQuery{CustomInlineTemplate: "PromQL:sum(memory)"}},
+	}}, local.Metrics...)
+	local.Classifier.GroupWeights = map[string]float64{"CPU": 40, "MEM": 60}
+	local.Classifier.Other = map[string]json.RawMessage{"scoreThresholds": json.RawMessage(`{"marginal":75,"pass":95}`)}
+
+	diffs, err := Diff(remote, local)
+	assert.Nil(t, err)
+	assert.Equal(t, []Difference{
+		{Kind: Changed, Subject: "description", From: `""`, To: `"latency of the web tier"`},
+		{Kind: Changed, Subject: "classifier", Fields: []FieldDifference{
+			{Path: "scoreThresholds.marginal", To: "75"},
+			{Path: "scoreThresholds.pass", To: "95"},
+		}},
+		{Kind: Changed, Subject: `metric "errors"`, Fields: []FieldDifference{
+			{Path: "analysisConfigurations.canary.critical", To: "true"},
+			{Path: "query.customInlineTemplate", From: `"PromQL:sum(errors)"`, To: `"PromQL:sum(errors_total)"`},
+		}},
+		{Kind: Removed, Subject: `metric "io"`},
+		{Kind: Added, Subject: `metric "memory"`},
+		{Kind: Changed, Subject: `group weight "CPU"`, From: "35", To: "40"},
+		{Kind: Removed, Subject: `group weight "IO"`, From: "65"},
+		{Kind: Added, Subject: `group weight "MEM"`, To: "60"},
+	}, diffs)
+
+	// reordering metrics and kayenta's own fields are no change
+	reordered := remote
+	reordered.Id, reordered.UpdatedTimestamp = "", 0
+	reordered.Metrics = []kayenta.Metric{remote.Metrics[1], remote.Metrics[0]}
+	diffs, err = Diff(remote, reordered)
+	assert.Nil(t, err)
+	assert.Empty(t, diffs)
+}
+
+func TestFindCanaryConfig(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(
+		storedConfig("latency", "web"),
+		storedConfig("errors", "api"),
+	))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	cc, err := FindCanaryConfig(ctx, c, "web", "latency")
+	assert.Nil(t, err)
+	assert.Equal(t, "latency", cc.Name)
+
+	// ids are found whatever the application
+	cc, err = FindCanaryConfig(ctx, c, "web", cc.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "latency", cc.Name)
+
+	_, err = FindCanaryConfig(ctx, c, "web", "errors")
+	assert.EqualError(t, err, "application web has no canary config named errors, and there is no canary config with that id")
+}
@@ -0,0 +1,117 @@
+package canaryConfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+const kayentaScheme = "kayenta://"
+
+// configID matches the UUIDs kayenta gives the canary configs it stores
+var configID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsConfigID reports whether location looks like the id of a canary config
+// stored in kayenta rather than a path. Other ids can be given as kayenta://<id>.
+func IsConfigID(location string) bool {
+	return configID.MatchString(location)
+}
+
+// ResolveCanaryConfig fetches a canary config from any location supported by
+// GetCanaryConfig, or from kayenta when the location is kayenta://<application>/<name>,
+// kayenta://<id> or the id of a config. A bare id is only used if there is no
+// file of that name, and if it isn't found in kayenta either both are reported.
+func ResolveCanaryConfig(ctx context.Context, location string, api kayenta.CanaryConfigAPI) (*kayenta.CanaryConfig, error) {
+	var (
+		cc  kayenta.CanaryConfig
+		err error
+	)
+	switch {
+	case strings.HasPrefix(location, kayentaScheme):
+		ref := strings.TrimPrefix(location, kayentaScheme)
+		if parts := strings.SplitN(ref, "/", 2); len(parts) == 2 {
+			cc, err = getByName(ctx, api, parts[0], parts[1])
+		} else {
+			cc, err = api.GetCanaryConfig(ctx, ref)
+		}
+	case IsConfigID(location) && !fileExists(location):
+		cc, err = api.GetCanaryConfig(ctx, location)
+		if err != nil {
+			return nil, fmt.Errorf("there is no file %s, and it could not be fetched from kayenta as a canary config id: %w", location, err)
+		}
+	default:
+		return GetCanaryConfig(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := cc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid canary config: %w", err)
+	}
+	return &cc, nil
+}
+
+// getByName looks up the id of the application's config with the given name.
+// kayenta only lists summaries of configs, so the config itself is fetched by id
+func getByName(ctx context.Context, api kayenta.CanaryConfigAPI, application, name string) (kayenta.CanaryConfig, error) {
+	if application == "" || name == "" {
+		return kayenta.CanaryConfig{}, fmt.Errorf("canary configs stored in kayenta are referenced as %s<application>/<name>", kayentaScheme)
+	}
+	configs, err := api.GetCanaryConfigs(ctx, application)
+	if err != nil {
+		return kayenta.CanaryConfig{}, err
+	}
+
+	var ids []string
+	for _, cc := range configs {
+		if cc.Name == name && containsApplication(cc, application) {
+			ids = append(ids, cc.Id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return kayenta.CanaryConfig{}, nameNotFoundError{application: application, name: name}
+	case 1:
+		return api.GetCanaryConfig(ctx, ids[0])
+	default:
+		return kayenta.CanaryConfig{}, fmt.Errorf("application %s has %d canary configs named %s, use one of their ids instead: %s",
+			application, len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+// FindCanaryConfig fetches the config of the application named ref from
+// kayenta, or the config with id ref if the application has no such config
+func FindCanaryConfig(ctx context.Context, api kayenta.CanaryConfigAPI, application, ref string) (kayenta.CanaryConfig, error) {
+	if application != "" {
+		cc, err := getByName(ctx, api, application, ref)
+		var notFound nameNotFoundError
+		if !errors.As(err, &notFound) {
+			return cc, err
+		}
+	}
+	cc, err := api.GetCanaryConfig(ctx, ref)
+	var serverErr kayenta.ServerError
+	if application != "" && errors.As(err, &serverErr) && serverErr.Code == http.StatusNotFound {
+		return cc, fmt.Errorf("application %s has no canary config named %s, and there is no canary config with that id", application, ref)
+	}
+	return cc, err
+}
+
+type nameNotFoundError struct {
+	application, name string
+}
+
+func (e nameNotFoundError) Error() string {
+	return fmt.Sprintf("application %s has no canary config named %s", e.application, e.name)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
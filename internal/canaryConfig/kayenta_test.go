@@ -0,0 +1,108 @@
+package canaryConfig
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
+	"github.com/stretchr/testify/assert"
+)
+
+func storedConfig(name, application string) kayenta.CanaryConfig {
+	cc := kayenta.CanaryConfig{
+		Name: name,
+		Metrics: []kayenta.Metric{{
+			Name:  "errors",
+			Query: kayenta.MetricQuery{Query: &kayenta.PrometheusQuery{CustomInlineTemplate: "PromQL:sum(errors)"}},
+		}},
+	}
+	if application != "" {
+		cc.Applications = []string{application}
+	}
+	return cc
+}
+
+func TestIsConfigID(t *testing.T) {
+	assert.True(t, IsConfigID("0c5a9e3e-4c1f-4b8a-9f0e-2c0d5e6f7a8b"))
+	assert.False(t, IsConfigID("canary.json"))
+	assert.False(t, IsConfigID("configs/canary"))
+	assert.False(t, IsConfigID("https://example.com/canary"))
+	assert.False(t, IsConfigID("canary-config"))
+	assert.False(t, IsConfigID(""))
+}
+
+func TestResolveCanaryConfig(t *testing.T) {
+	shared := storedConfig("shared", "web")
+	shared.Id = "0c5a9e3e-4c1f-4b8a-9f0e-2c0d5e6f7a8b"
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(
+		shared,
+		storedConfig("latency", "web"),
+		storedConfig("latency", "api"),
+		storedConfig("errors", "api"),
+		storedConfig("errors", "api"),
+	))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	ids := map[string]string{}
+	for _, cc := range server.CanaryConfigs() {
+		ids[cc.Applications[0]+"/"+cc.Name] = cc.Id
+	}
+
+	cc, err := ResolveCanaryConfig(ctx, "kayenta://api/latency", c)
+	assert.Nil(t, err)
+	assert.Equal(t, ids["api/latency"], cc.Id)
+
+	cc, err = ResolveCanaryConfig(ctx, "kayenta://"+ids["web/latency"], c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"web"}, cc.Applications)
+
+	cc, err = ResolveCanaryConfig(ctx, shared.Id, c)
+	assert.Nil(t, err)
+	assert.Equal(t, "shared", cc.Name)
+
+	_, err = ResolveCanaryConfig(ctx, "4d1f2a3b-0000-4000-8000-000000000000", c)
+	assert.Contains(t, err.Error(), "there is no file 4d1f2a3b-0000-4000-8000-000000000000, and it could not be fetched from kayenta")
+	assert.Contains(t, err.Error(), "404")
+
+	// anything else that isn't a file is a missing file, not a config in kayenta
+	_, err = ResolveCanaryConfig(ctx, "canary-config", c)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	_, err = ResolveCanaryConfig(ctx, "kayenta://web/missing", c)
+	assert.EqualError(t, err, "application web has no canary config named missing")
+
+	_, err = ResolveCanaryConfig(ctx, "kayenta://api/errors", c)
+	assert.Contains(t, err.Error(), "application api has 2 canary configs named errors")
+
+	_, err = ResolveCanaryConfig(ctx, "kayenta://web/", c)
+	assert.NotNil(t, err)
+}
+
+func TestResolveCanaryConfigPrefersFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "canaryconfig")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	assert.Nil(t, os.Chdir(dir))
+
+	config := `{"name": "local", "metrics": [{"name": "errors", "query": {"type": "prometheus", "customInlineTemplate": "PromQL:sum(errors)"}}]}`
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "canary"), []byte(config), 0644))
+
+	// the client is never used, so a nil client would panic if it were
+	cc, err := ResolveCanaryConfig(context.Background(), "canary", (*kayenta.DefaultClient)(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "local", cc.Name)
+
+	id := "0c5a9e3e-4c1f-4b8a-9f0e-2c0d5e6f7a8b"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, id), []byte(config), 0644))
+	cc, err = ResolveCanaryConfig(context.Background(), id, (*kayenta.DefaultClient)(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "local", cc.Name)
+}
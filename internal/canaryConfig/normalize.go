@@ -0,0 +1,80 @@
+package canaryConfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// Normalize strips the fields of a canary config that kayenta manages, like
+// its id and timestamps, so that a config read from a file can be compared to
+// the copy stored in kayenta
+func Normalize(cc kayenta.CanaryConfig) kayenta.CanaryConfig {
+	cc.Id = ""
+	cc.CreatedTimestamp, cc.CreatedTimestampIso = 0, ""
+	cc.UpdatedTimestamp, cc.UpdatedTimestampIso = 0, ""
+	cc.Applications = append([]string(nil), cc.Applications...)
+	sort.Strings(cc.Applications)
+	return cc
+}
+
+// Equal reports whether two canary configs are the same once normalized.
+// Missing and empty values are considered equal, since kayenta and config
+// files differ in which of them they leave out.
+func Equal(a, b kayenta.CanaryConfig) (bool, error) {
+	na, err := normalizedValue(Normalize(a))
+	if err != nil {
+		return false, err
+	}
+	nb, err := normalizedValue(Normalize(b))
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(na, nb), nil
+}
+
+// normalizedValue converts v to its generic JSON representation without any
+// null or empty values
+func normalizedValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+	return pruneEmpty(generic), nil
+}
+
+func pruneEmpty(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, field := range value {
+			if pruned := pruneEmpty(field); pruned == nil {
+				delete(value, k)
+			} else {
+				value[k] = pruned
+			}
+		}
+		if len(value) == 0 {
+			return nil
+		}
+		return value
+	case []interface{}:
+		if len(value) == 0 {
+			return nil
+		}
+		for i, item := range value {
+			value[i] = pruneEmpty(item)
+		}
+		return value
+	case string:
+		if value == "" {
+			return nil
+		}
+	}
+	return v
+}
@@ -31,6 +31,9 @@ func GetCanaryConfig(location string) (*kayenta.CanaryConfig, error) {
 	if err := parseYamlOrJson(b, &input); err != nil {
 		return nil, fmt.Errorf("failed to deserialize canary config: %w", err)
 	}
+	if err := input.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid canary config: %w", err)
+	}
 	return &input, nil
 }
 
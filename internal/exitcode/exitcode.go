@@ -0,0 +1,96 @@
+// Package exitcode defines the exit codes of kayentactl. The codes are part of
+// the CLI's contract with scripts and pipelines, so existing codes must never
+// change meaning.
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Pass means the analysis met the pass threshold
+	Pass = 0
+	// Fail means the final score was below the marginal threshold
+	Fail = 1
+	// Marginal means the final score was at or above the marginal threshold
+	// but below the pass threshold
+	Marginal = 2
+	// ExecutionError means the execution ended without a usable score, e.g. a
+	// metrics provider failed or the execution was cancelled in kayenta
+	ExecutionError = 3
+	// Timeout means the analysis did not complete before --timeout elapsed or
+	// kayentactl was interrupted while waiting for it
+	Timeout = 4
+	// ClientError means kayentactl was used incorrectly, e.g. invalid flags, an
+	// unreadable canary config or a request kayenta rejected
+	ClientError = 5
+	// ServerUnreachable means kayenta could not be reached or was unavailable
+	ServerUnreachable = 6
+	// Running means the analysis has not completed yet
+	Running = 7
+	// Conflict means a canary config was not written because it was changed
+	// in kayenta after it was read
+	Conflict = 8
+)
+
+// Differ means config diff found differences. like diff(1), it shares its
+// value with Fail since the two are never returned by the same command.
+const Differ = 1
+
+// FromError maps an error returned while talking to kayenta to an exit code
+func FromError(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return Timeout
+	}
+
+	var conflictErr kayenta.ConflictError
+	if errors.As(err, &conflictErr) {
+		return Conflict
+	}
+
+	var serverErr kayenta.ServerError
+	if errors.As(err, &serverErr) {
+		switch {
+		case serverErr.Code == http.StatusBadGateway,
+			serverErr.Code == http.StatusServiceUnavailable,
+			serverErr.Code == http.StatusGatewayTimeout:
+			return ServerUnreachable
+		case serverErr.Code >= 400 && serverErr.Code < 500:
+			return ClientError
+		default:
+			return ExecutionError
+		}
+	}
+
+	// the http client wraps every transport failure, like a refused
+	// connection or a failed DNS lookup, in a url.Error
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return ServerUnreachable
+	}
+	return ExecutionError
+}
+
+// FromLookupError maps an error returned while looking up or reading a canary
+// config to an exit code. a config that can't be found or parsed is the
+// caller's mistake, unless kayenta couldn't be reached to look it up
+func FromLookupError(err error) int {
+	code := FromError(err)
+	if code == ExecutionError {
+		return ClientError
+	}
+	return code
+}
+
+// Exit logs the message as an error and exits with code
+func Exit(code int, format string, args ...interface{}) {
+	log.Errorf(format, args...)
+	os.Exit(code)
+}
@@ -0,0 +1,47 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	server := kayentatest.NewServer()
+	url := server.URL
+	server.Close()
+	_, unreachable := kayenta.NewDefaultClient(kayenta.ClientBaseURL(url)).GetCredentials(context.Background())
+
+	tests := map[string]struct {
+		err      error
+		expected int
+	}{
+		"deadline":          {err: context.DeadlineExceeded, expected: Timeout},
+		"interrupted":       {err: fmt.Errorf("waiting: %w", context.Canceled), expected: Timeout},
+		"connection":        {err: unreachable, expected: ServerUnreachable},
+		"unavailable":       {err: kayenta.ServerError{Code: http.StatusServiceUnavailable}, expected: ServerUnreachable},
+		"not found":         {err: kayenta.ServerError{Code: http.StatusNotFound}, expected: ClientError},
+		"internal error":    {err: kayenta.ServerError{Code: http.StatusInternalServerError}, expected: ExecutionError},
+		"conflict":          {err: fmt.Errorf("push: %w", kayenta.ConflictError{ID: "some-id"}), expected: Conflict},
+		"unexpected errors": {err: errors.New("boom"), expected: ExecutionError},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, FromError(test.err))
+		})
+	}
+}
+
+func TestFromLookupError(t *testing.T) {
+	assert.Equal(t, ClientError, FromLookupError(errors.New("invalid canary config")))
+	assert.Equal(t, ClientError, FromLookupError(kayenta.ServerError{Code: http.StatusNotFound}))
+	assert.Equal(t, ServerUnreachable, FromLookupError(kayenta.ServerError{Code: http.StatusBadGateway}))
+	assert.Equal(t, Timeout, FromLookupError(context.DeadlineExceeded))
+}
@@ -1,6 +1,14 @@
 package options
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -10,11 +18,36 @@ func ConfigureGlobals(cmd *cobra.Command) {
 	persistentFlags.StringP("kayenta-url", "u", "http://localhost:8090", "kayenta url")
 	persistentFlags.StringP("verbosity", "v", log.InfoLevel.String(), "log level (debug, info, warn, error, fatal, panic)")
 	persistentFlags.Bool("no-color", false, "disable output colors")
+
+	defaultRetry := kayenta.DefaultRetryPolicy()
+	persistentFlags.Int("retry-max-attempts", defaultRetry.MaxAttempts, "maximum number of attempts for each request to kayenta. 1 disables retries")
+	persistentFlags.Duration("retry-backoff", defaultRetry.InitialBackoff, "time to wait before the first retry, doubled on each subsequent retry")
+	persistentFlags.Duration("retry-max-backoff", defaultRetry.MaxBackoff, "maximum time to wait between retries")
+	persistentFlags.IntSlice("retry-status-codes", defaultRetry.RetryableStatusCodes, "response codes from kayenta that are retried")
+	persistentFlags.Bool("retry-non-idempotent", false, "also retry requests that may create duplicate resources, like starting an analysis")
+	persistentFlags.Int("retry-max-poll-failures", 10, "number of polls in a row that may fail because kayenta is unavailable before waiting for an analysis is given up")
+
+	persistentFlags.String("auth-token", "", "static bearer token sent to kayenta")
+	persistentFlags.String("auth-token-file", "", "file containing a bearer token, re-read when the token expires")
+	persistentFlags.String("auth-token-command", "", "shell command that prints a bearer token, run with sh -c and re-run when the token expires")
+	persistentFlags.Duration("auth-token-ttl", 5*time.Minute, "how long tokens from --auth-token-file or --auth-token-command are cached if they are not JWTs")
+	persistentFlags.String("auth-username", "", "username for basic auth")
+	persistentFlags.String("auth-password", "", "password for basic auth. visible to other users of the machine, prefer --auth-password-file")
+	persistentFlags.String("auth-password-file", "", "file containing the password for basic auth")
+	persistentFlags.String("tls-client-cert", "", "client certificate for mTLS")
+	persistentFlags.String("tls-client-key", "", "client key for mTLS")
+	persistentFlags.String("tls-ca-cert", "", "CA bundle used to verify kayenta's certificate")
 }
 
 type GlobalOptions struct {
 	KayentaURL, Verbosity string
 	NoColor               bool
+	RetryPolicy           kayenta.RetryPolicy
+	Authenticator         kayenta.Authenticator
+	TLSConfig             *tls.Config
+
+	// MaxPollFailures is how many polls for an analysis may fail in a row
+	MaxPollFailures int
 }
 
 func Globals(cmd *cobra.Command) (*GlobalOptions, error) {
@@ -24,9 +57,103 @@ func Globals(cmd *cobra.Command) (*GlobalOptions, error) {
 	verbosity, _ := flags.GetString("verbosity")
 	noColor, _ := flags.GetBool("no-color")
 
+	retryPolicy := kayenta.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts, _ = flags.GetInt("retry-max-attempts")
+	retryPolicy.InitialBackoff, _ = flags.GetDuration("retry-backoff")
+	retryPolicy.MaxBackoff, _ = flags.GetDuration("retry-max-backoff")
+	retryPolicy.RetryableStatusCodes, _ = flags.GetIntSlice("retry-status-codes")
+	retryPolicy.RetryNonIdempotent, _ = flags.GetBool("retry-non-idempotent")
+	maxPollFailures, _ := flags.GetInt("retry-max-poll-failures")
+
+	authenticator, err := authenticatorFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	certFile, _ := flags.GetString("tls-client-cert")
+	keyFile, _ := flags.GetString("tls-client-key")
+	caFile, _ := flags.GetString("tls-ca-cert")
+	if certFile != "" || keyFile != "" || caFile != "" {
+		if tlsConfig, err = kayenta.LoadTLSConfig(certFile, keyFile, caFile); err != nil {
+			return nil, err
+		}
+	}
+
 	return &GlobalOptions{
-		KayentaURL: kayentaURL,
-		Verbosity:  verbosity,
-		NoColor:    noColor,
+		KayentaURL:      kayentaURL,
+		Verbosity:       verbosity,
+		NoColor:         noColor,
+		RetryPolicy:     retryPolicy,
+		MaxPollFailures: maxPollFailures,
+		Authenticator:   authenticator,
+		TLSConfig:       tlsConfig,
 	}, nil
 }
+
+// authenticatorFromFlags returns the kayenta.Authenticator for the auth flags
+// that were set, or nil if kayenta does not require authentication. at most one
+// kind of authentication can be used.
+func authenticatorFromFlags(cmd *cobra.Command) (kayenta.Authenticator, error) {
+	flags := cmd.Root().PersistentFlags()
+
+	token, _ := flags.GetString("auth-token")
+	tokenFile, _ := flags.GetString("auth-token-file")
+	tokenCommand, _ := flags.GetString("auth-token-command")
+	tokenTTL, _ := flags.GetDuration("auth-token-ttl")
+	username, _ := flags.GetString("auth-username")
+	password, _ := flags.GetString("auth-password")
+	passwordFile, _ := flags.GetString("auth-password-file")
+	if passwordFile != "" {
+		if password != "" {
+			return nil, errors.New("only one of --auth-password or --auth-password-file can be used")
+		}
+		b, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read password file: %w", err)
+		}
+		// editors and echo leave a trailing newline
+		password = strings.TrimRight(string(b), "\r\n")
+	}
+
+	var authenticators []kayenta.Authenticator
+	if token != "" {
+		authenticators = append(authenticators, kayenta.BearerToken(token))
+	}
+	if tokenFile != "" {
+		authenticators = append(authenticators, kayenta.NewBearerTokenAuth(kayenta.FileTokenSource{Path: tokenFile}, tokenTTL))
+	}
+	if tokenCommand != "" {
+		// run through the shell, so that quoted arguments and pipes work
+		authenticators = append(authenticators, kayenta.NewBearerTokenAuth(kayenta.CommandTokenSource{Command: []string{"sh", "-c", tokenCommand}}, tokenTTL))
+	}
+	if username != "" || password != "" {
+		authenticators = append(authenticators, kayenta.BasicAuth{Username: username, Password: password})
+	}
+
+	switch len(authenticators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return authenticators[0], nil
+	default:
+		return nil, errors.New("only one of --auth-token, --auth-token-file, --auth-token-command or basic auth can be used")
+	}
+}
+
+// ClientOptions returns the options needed to construct a kayenta client
+// configured from the global flags. sub-commands should always use these
+// so that every command talks to kayenta the same way
+func (g *GlobalOptions) ClientOptions() []func(dc *kayenta.DefaultClient) {
+	opts := []func(dc *kayenta.DefaultClient){
+		kayenta.ClientBaseURL(g.KayentaURL),
+		kayenta.ClientRetryPolicy(g.RetryPolicy),
+	}
+	if g.TLSConfig != nil {
+		opts = append(opts, kayenta.ClientTLSConfig(g.TLSConfig))
+	}
+	if g.Authenticator != nil {
+		opts = append(opts, kayenta.ClientAuthenticator(g.Authenticator))
+	}
+	return opts
+}
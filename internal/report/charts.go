@@ -0,0 +1,225 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/fatih/color"
+)
+
+const (
+	controlMarker    = 'c'
+	experimentMarker = 'e'
+	// bothMarker is drawn where the control and the experiment overlap
+	bothMarker = '*'
+)
+
+// ChartOptions controls the size of the charts drawn by MetricChart
+type ChartOptions struct {
+	// Width is the number of columns of the plot area
+	Width int
+	// Height is the number of rows of the line chart
+	Height int
+	// Line and Box select which charts are drawn
+	Line, Box bool
+}
+
+// minChartSize is the smallest width and height that a chart can be drawn in
+const minChartSize = 2
+
+// Validate checks that the charts are big enough to be drawn
+func (o ChartOptions) Validate() error {
+	if o.Width < minChartSize {
+		return fmt.Errorf("chart width must be at least %d, got %d", minChartSize, o.Width)
+	}
+	if o.Line && o.Height < minChartSize {
+		return fmt.Errorf("chart height must be at least %d, got %d", minChartSize, o.Height)
+	}
+	return nil
+}
+
+// MetricChart draws the control and experiment series of a metric as a line
+// chart and box plots. result is how the judge classified the metric, if known.
+// Options that don't pass Validate are reported in place of the charts.
+func MetricChart(pair kayenta.MetricSetPair, result *kayenta.MetricResult, opts ChartOptions) string {
+	var b strings.Builder
+	title := pair.Name
+	if result != nil {
+		title = fmt.Sprintf("%s: %s", pair.Name, colorize(strings.ToUpper(result.Classification)))
+		if result.ClassificationReason != "" {
+			title += fmt.Sprintf(" (%s)", result.ClassificationReason)
+		}
+	}
+	fmt.Fprintln(&b, title)
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintln(&b, err.Error())
+		return b.String()
+	}
+
+	control, experiment := floats(pair.Control()), floats(pair.Experiment())
+	if len(finite(control)) == 0 && len(finite(experiment)) == 0 {
+		fmt.Fprintln(&b, "no data")
+		return b.String()
+	}
+	lo, hi := bounds(append(finite(control), finite(experiment)...))
+
+	if opts.Line {
+		fmt.Fprintln(&b, lineChart(control, experiment, lo, hi, opts.Width, opts.Height))
+		fmt.Fprintf(&b, "%s control  %s experiment  %s both\n\n",
+			color.BlueString(string(controlMarker)), color.MagentaString(string(experimentMarker)), string(bothMarker))
+	}
+	if opts.Box {
+		fmt.Fprintln(&b, boxPlot("control", control, lo, hi, opts.Width, color.BlueString))
+		fmt.Fprintln(&b, boxPlot("experiment", experiment, lo, hi, opts.Width, color.MagentaString))
+	}
+	return b.String()
+}
+
+func floats(series []kayenta.Float) []float64 {
+	values := make([]float64, len(series))
+	for i, v := range series {
+		values[i] = float64(v)
+	}
+	return values
+}
+
+// finite drops missing and infinite values
+func finite(values []float64) []float64 {
+	var kept []float64
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func bounds(values []float64) (float64, float64) {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if lo == hi {
+		// give a flat series some room so that it is drawn in the middle
+		lo, hi = lo-1, hi+1
+	}
+	return lo, hi
+}
+
+// scale maps v from [lo, hi] onto [0, steps-1]
+func scale(v, lo, hi float64, steps int) int {
+	i := int(math.Round((v - lo) / (hi - lo) * float64(steps-1)))
+	if i < 0 {
+		return 0
+	}
+	if i >= steps {
+		return steps - 1
+	}
+	return i
+}
+
+func formatAxis(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// lineChart plots both series over width columns and height rows, with the
+// y axis labelled on the left. Series are stretched or squeezed to the width.
+func lineChart(control, experiment []float64, lo, hi float64, width, height int) string {
+	grid := make([][]rune, height)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", width))
+	}
+	plot := func(series []float64, marker rune) {
+		for i, v := range series {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			x := 0
+			if len(series) > 1 {
+				x = i * (width - 1) / (len(series) - 1)
+			}
+			y := height - 1 - scale(v, lo, hi, height)
+			if current := grid[y][x]; current != ' ' && current != marker {
+				grid[y][x] = bothMarker
+				continue
+			}
+			grid[y][x] = marker
+		}
+	}
+	plot(control, controlMarker)
+	plot(experiment, experimentMarker)
+
+	labels := map[int]string{0: formatAxis(hi), height - 1: formatAxis(lo)}
+	if height > 2 {
+		labels[height/2] = formatAxis(lo + (hi-lo)*float64(height-1-height/2)/float64(height-1))
+	}
+	labelWidth := 0
+	for _, label := range labels {
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+
+	var lines []string
+	for y, row := range grid {
+		var line strings.Builder
+		for _, r := range row {
+			switch r {
+			case controlMarker:
+				line.WriteString(color.BlueString(string(r)))
+			case experimentMarker:
+				line.WriteString(color.MagentaString(string(r)))
+			default:
+				line.WriteRune(r)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%*s ┤%s", labelWidth, labels[y], line.String()))
+	}
+	lines = append(lines, fmt.Sprintf("%*s └%s", labelWidth, "", strings.Repeat("─", width)))
+	return strings.Join(lines, "\n")
+}
+
+// quartiles returns the min, first quartile, median, third quartile and max
+func quartiles(values []float64) [5]float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	quantile := func(q float64) float64 {
+		pos := q * float64(len(sorted)-1)
+		lower := int(math.Floor(pos))
+		upper := int(math.Ceil(pos))
+		return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+	}
+	return [5]float64{sorted[0], quantile(0.25), quantile(0.5), quantile(0.75), sorted[len(sorted)-1]}
+}
+
+// boxPlot draws the distribution of a series as |---[==|==]---| on the same
+// scale as the line chart, followed by the five numbers it is drawn from
+func boxPlot(label string, series []float64, lo, hi float64, width int, paint func(format string, a ...interface{}) string) string {
+	values := finite(series)
+	if len(values) == 0 {
+		return fmt.Sprintf("%-10s no data", label)
+	}
+	q := quartiles(values)
+	var pos [5]int
+	for i, v := range q {
+		pos[i] = scale(v, lo, hi, width)
+	}
+
+	row := []rune(strings.Repeat(" ", width))
+	for x := pos[0]; x <= pos[4]; x++ {
+		row[x] = '-'
+	}
+	for x := pos[1]; x <= pos[3]; x++ {
+		row[x] = '='
+	}
+	row[pos[0]], row[pos[4]] = '|', '|'
+	row[pos[1]], row[pos[3]] = '[', ']'
+	row[pos[2]] = '|'
+
+	return fmt.Sprintf("%-10s %s\n%-10s min %s  q1 %s  median %s  q3 %s  max %s", label, paint(string(row)), "",
+		formatAxis(q[0]), formatAxis(q[1]), formatAxis(q[2]), formatAxis(q[3]), formatAxis(q[4]))
+}
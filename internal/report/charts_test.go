@@ -0,0 +1,69 @@
+package report
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/fatih/color"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuartiles(t *testing.T) {
+	assert.Equal(t, [5]float64{1, 2, 3, 4, 5}, quartiles([]float64{5, 3, 1, 4, 2}))
+	assert.Equal(t, [5]float64{1, 1.75, 2.5, 3.25, 4}, quartiles([]float64{4, 1, 3, 2}))
+}
+
+func TestBoxPlot(t *testing.T) {
+	color.NoColor = true
+	plot := boxPlot("control", []float64{0, 2, 5, 8, 10, math.NaN()}, 0, 10, 11, color.BlueString)
+	lines := strings.Split(plot, "\n")
+	assert.Equal(t, "control    |-[==|==]-|", lines[0])
+	assert.Equal(t, "           min 0  q1 2  median 5  q3 8  max 10", lines[1])
+
+	assert.Equal(t, "control    no data", boxPlot("control", []float64{math.NaN()}, 0, 10, 11, color.BlueString))
+}
+
+func TestMetricChart(t *testing.T) {
+	color.NoColor = true
+	nan := kayenta.Float(math.NaN())
+	pair := kayenta.MetricSetPair{
+		Name: "errors",
+		Values: map[string][]kayenta.Float{
+			"control":    {1, 1, 1},
+			"experiment": {1, 3, nan},
+		},
+	}
+	result := &kayenta.MetricResult{Classification: "High", ClassificationReason: "too many errors"}
+
+	chart := MetricChart(pair, result, ChartOptions{Width: 3, Height: 3, Line: true})
+	assert.Equal(t, strings.Join([]string{
+		"errors: HIGH (too many errors)",
+		"3 ┤ e ",
+		"2 ┤   ",
+		"1 ┤*cc",
+		"  └───",
+		"c control  e experiment  * both",
+		"",
+		"",
+	}, "\n"), chart)
+
+	empty := kayenta.MetricSetPair{Name: "errors", Values: map[string][]kayenta.Float{"control": {nan}}}
+	assert.Equal(t, "errors\nno data\n", MetricChart(empty, nil, ChartOptions{Width: 3, Height: 3, Line: true, Box: true}))
+}
+
+func TestChartOptionsValidate(t *testing.T) {
+	assert.Nil(t, ChartOptions{Width: 2, Height: 2, Line: true, Box: true}.Validate())
+	assert.Nil(t, ChartOptions{Width: 2, Box: true}.Validate())
+	assert.NotNil(t, ChartOptions{Width: 0, Box: true}.Validate())
+	assert.NotNil(t, ChartOptions{Width: -5, Height: 12, Line: true}.Validate())
+	assert.NotNil(t, ChartOptions{Width: 60, Height: 0, Line: true}.Validate())
+
+	pair := kayenta.MetricSetPair{Name: "errors", Values: map[string][]kayenta.Float{"control": {1, 2}, "experiment": {3, 4}}}
+	for _, opts := range []ChartOptions{{Width: 0, Height: 12, Line: true, Box: true}, {Width: 60, Height: 0, Line: true}, {Width: -1, Height: -1, Line: true, Box: true}} {
+		chart := MetricChart(pair, nil, opts)
+		assert.Contains(t, chart, "must be at least 2")
+	}
+}
@@ -0,0 +1,263 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/armory-io/kayentactl/internal/analysis"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// htmlReport is a single page without any external resources so that it can
+// be archived with a release and opened offline
+const htmlReport = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Canary analysis {{ .ID }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; margin: 2em auto; max-width: 960px; padding: 0 1em; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; border-bottom: 1px solid #e1e4e8; padding-bottom: .3em; margin-top: 2em; }
+.badge { display: inline-block; border-radius: 4px; color: #fff; font-weight: bold; padding: .2em .6em; text-transform: uppercase; }
+.passed, .SUCCEEDED { background: #2da44e; }
+.marginal { background: #d4a72c; }
+.failed, .error, .TERMINAL, .CANCELED { background: #cf222e; }
+.running, .RUNNING { background: #0969da; }
+.NOT_STARTED, .SKIPPED { background: #8c959f; }
+.summary td { padding: .2em 1em .2em 0; }
+table.data { border-collapse: collapse; width: 100%; }
+table.data th, table.data td { border: 1px solid #d0d7de; padding: .4em .6em; text-align: left; }
+table.data th { background: #f6f8fa; cursor: pointer; user-select: none; }
+.classification { border-radius: 4px; color: #fff; font-size: .85em; padding: .1em .5em; }
+.PASS { background: #2da44e; }
+.HIGH, .LOW, .NODATA, .ERROR { background: #cf222e; }
+.bar { background: #eaeef2; border-radius: 4px; height: 1.2em; position: relative; }
+.bar div { border-radius: 4px; height: 100%; }
+.bar span { font-size: .85em; left: .5em; position: absolute; top: .1em; }
+.group { display: grid; grid-template-columns: 12em 1fr; gap: 1em; margin: .4em 0; }
+.timeline { display: flex; flex-wrap: wrap; gap: .3em; }
+.stage { border-radius: 4px; color: #fff; flex: 1 1 10em; padding: .4em .6em; }
+.stage small { display: block; opacity: .85; }
+svg text { fill: #57606a; font-size: 11px; }
+</style>
+</head>
+<body>
+<h1>Canary analysis {{ .ID }} <span class="badge {{ .Outcome }}">{{ .Outcome }}</span></h1>
+<table class="summary">
+<tr><td>Status</td><td>{{ .Status }}</td></tr>
+<tr><td>Final score</td><td><strong>{{ .FinalScore }}</strong></td></tr>
+<tr><td>Thresholds</td><td>marginal {{ .Thresholds.Marginal }}, pass {{ .Thresholds.Pass }}</td></tr>
+{{- if .Message }}
+<tr><td>Message</td><td>{{ .Message }}</td></tr>
+{{- end }}
+</table>
+{{- if .Scores }}
+
+<h2>Score trend</h2>
+{{ .ScoreTrend }}
+{{- end }}
+{{- if .Groups }}
+
+<h2>Group scores</h2>
+{{- range .Groups }}
+<div class="group"><div>{{ .Name }}</div><div class="bar"><div class="{{ .Outcome }}" style="width: {{ .Width }}%"></div><span>{{ .Score }}</span></div></div>
+{{- end }}
+{{- end }}
+{{- if .Metrics }}
+
+<h2>Metrics</h2>
+<label><input type="checkbox" id="failures-only"> Only show failed metrics</label>
+<table class="data" id="metrics">
+<thead><tr><th>Metric</th><th>Groups</th><th>Classification</th><th>Control mean</th><th>Experiment mean</th><th>Difference</th><th>Reason</th></tr></thead>
+<tbody>
+{{- range .Metrics }}
+<tr data-failed="{{ failed .Classification }}"><td>{{ .Name }}</td><td>{{ join .Groups ", " }}</td><td><span class="classification {{ upper .Classification }}">{{ .Classification }}</span></td><td>{{ mean .Control }}</td><td>{{ mean .Experiment }}</td><td>{{ difference . }}</td><td>{{ .ClassificationReason }}</td></tr>
+{{- end }}
+</tbody>
+</table>
+{{- end }}
+{{- if .Stages }}
+
+<h2>Stages</h2>
+<div class="timeline">
+{{- range .Stages }}
+<div class="stage {{ .Status }}">{{ .Name }}<small>{{ .StageType }} &middot; {{ .Status }}{{ with .Duration }} &middot; {{ . }}{{ end }}</small>{{ with .ErrorMessage }}<small>{{ . }}</small>{{ end }}</div>
+{{- end }}
+</div>
+{{- end }}
+<script>
+(function () {
+  var table = document.getElementById("metrics");
+  if (!table) { return; }
+  var body = table.tBodies[0];
+  document.getElementById("failures-only").addEventListener("change", function (e) {
+    Array.prototype.forEach.call(body.rows, function (row) {
+      row.style.display = e.target.checked && row.dataset.failed !== "true" ? "none" : "";
+    });
+  });
+  Array.prototype.forEach.call(table.tHead.rows[0].cells, function (th, column) {
+    var ascending = true;
+    th.addEventListener("click", function () {
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[column].textContent, y = b.cells[column].textContent;
+        return ascending ? x.localeCompare(y) : y.localeCompare(x);
+      });
+      ascending = !ascending;
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`
+
+type htmlReportData struct {
+	ID         string
+	Outcome    analysis.Outcome
+	Status     string
+	FinalScore string
+	Thresholds kayenta.Threshold
+	Message    string
+	Scores     []float64
+	ScoreTrend template.HTML
+	Groups     []htmlGroup
+	Metrics    []kayenta.MetricResult
+	Stages     []kayenta.StageStatus
+}
+
+type htmlGroup struct {
+	Name    string
+	Score   string
+	Width   float64
+	Outcome analysis.Outcome
+}
+
+var htmlFuncs = template.FuncMap{
+	"failed":     isFailedClassification,
+	"join":       strings.Join,
+	"upper":      strings.ToUpper,
+	"mean":       formatMean,
+	"difference": formatDifference,
+}
+
+// HTMLReport renders the result as a self-contained HTML page with charts of
+// the scores, the metrics and the stages of the execution
+func HTMLReport(result kayenta.GetStandaloneCanaryAnalysisOutput) ([]byte, error) {
+	tmpl, err := template.New("htmlReport").Funcs(htmlFuncs).Parse(htmlReport)
+	if err != nil {
+		return nil, err
+	}
+
+	executionResult := result.CanaryAnalysisExecutionResult
+	thresholds := result.CanaryAnalysisExecutionRequest.Thresholds
+	data := htmlReportData{
+		ID:         result.PipelineID,
+		Outcome:    analysis.ResultOutcome(result),
+		Status:     result.ExecutionStatus,
+		FinalScore: finalScore(executionResult.CanaryScores),
+		Thresholds: thresholds,
+		Message:    executionResult.CanaryScoreMessage,
+		Scores:     executionResult.CanaryScores,
+		ScoreTrend: scoreTrendSVG(executionResult.CanaryScores, thresholds),
+		Stages:     result.Stages,
+	}
+	if data.FinalScore == "" {
+		data.FinalScore = "none"
+	}
+	if results := executionResult.CanaryExecutionResults; len(results) > 0 {
+		judgeResult := results[len(results)-1].Result.JudgeResult
+		data.Metrics = judgeResult.Results
+		for _, group := range judgeResult.GroupScores {
+			data.Groups = append(data.Groups, htmlGroup{
+				Name:    group.Name,
+				Score:   formatScore(group.Score),
+				Width:   clampScore(group.Score),
+				Outcome: scoreOutcome(group.Score, thresholds),
+			})
+		}
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// scoreOutcome classifies a score against the thresholds, which is used to
+// color it
+func scoreOutcome(score float64, thresholds kayenta.Threshold) analysis.Outcome {
+	if pass, err := strconv.ParseFloat(thresholds.Pass, 64); err == nil && score >= pass {
+		return analysis.OutcomePass
+	}
+	if marginal, err := strconv.ParseFloat(thresholds.Marginal, 64); err == nil && score >= marginal {
+		return analysis.OutcomeMarginal
+	}
+	return analysis.OutcomeFail
+}
+
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
+// scoreTrendSVG draws the score of every interval as a line chart, with the
+// thresholds as dashed lines. Scores range from 0 to 100.
+func scoreTrendSVG(scores []float64, thresholds kayenta.Threshold) template.HTML {
+	if len(scores) == 0 {
+		return ""
+	}
+
+	const (
+		width, height = 640.0, 200.0
+		left, right   = 30.0, 10.0
+		top, bottom   = 10.0, 20.0
+	)
+	plotWidth, plotHeight := width-left-right, height-top-bottom
+	x := func(i int) float64 {
+		if len(scores) == 1 {
+			return left + plotWidth/2
+		}
+		return left + plotWidth*float64(i)/float64(len(scores)-1)
+	}
+	y := func(score float64) float64 {
+		return top + plotHeight*(1-clampScore(score)/100)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %g %g" width="100%%" role="img" aria-label="score per interval">`, width, height)
+	for _, tick := range []float64{0, 50, 100} {
+		fmt.Fprintf(&b, `<line x1="%g" x2="%g" y1="%.1f" y2="%.1f" stroke="#eaeef2"/>`, left, width-right, y(tick), y(tick))
+		fmt.Fprintf(&b, `<text x="%g" y="%.1f" text-anchor="end">%g</text>`, left-4, y(tick)+4, tick)
+	}
+	for _, threshold := range []struct {
+		value, color string
+	}{{thresholds.Marginal, "#d4a72c"}, {thresholds.Pass, "#2da44e"}} {
+		if v, err := strconv.ParseFloat(threshold.value, 64); err == nil {
+			fmt.Fprintf(&b, `<line x1="%g" x2="%g" y1="%.1f" y2="%.1f" stroke="%s" stroke-dasharray="4 4"/>`, left, width-right, y(v), y(v), threshold.color)
+		}
+	}
+
+	var points []string
+	for i, score := range scores {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(score)))
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#0969da" stroke-width="2"/>`, strings.Join(points, " "))
+	for i, score := range scores {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="4" fill="#0969da"><title>interval %d: %s</title></circle>`, x(i), y(score), i+1, formatScore(score))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%g" text-anchor="middle">%d</text>`, x(i), height-4, i+1)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,28 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLReport(t *testing.T) {
+	result := testResult()
+	result.Stages = []kayenta.StageStatus{{StageType: "runCanary", Name: "Run Canary #1", Status: "SUCCEEDED"}}
+	result.CanaryAnalysisExecutionResult.CanaryExecutionResults[1].Result.JudgeResult.Results[0].ClassificationReason = "<script>alert(1)</script>"
+
+	b, err := HTMLReport(result)
+	assert.Nil(t, err)
+	html := string(b)
+
+	assert.Contains(t, html, `<span class="badge marginal">marginal</span>`)
+	assert.Contains(t, html, `<polyline points="30.0,10.0 630.0,73.8"`)
+	assert.Contains(t, html, `<div class="marginal" style="width: 50%"></div><span>50</span>`)
+	assert.Contains(t, html, `<span class="classification HIGH">High</span>`)
+	assert.Contains(t, html, `<div class="stage SUCCEEDED">Run Canary #1<small>runCanary &middot; SUCCEEDED</small></div>`)
+	assert.Contains(t, html, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, html, "http://")
+	assert.NotContains(t, html, "https://")
+}
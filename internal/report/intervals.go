@@ -0,0 +1,128 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/olekukonko/tablewriter"
+)
+
+// sparkChars are the bars of a sparkline, from a score of 0 to 100
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// classificationOrder is the order classifications are listed in
+var classificationOrder = []string{"PASS", "HIGH", "LOW", "NODATA", "ERROR"}
+
+// interval is the outcome of one canary run of an analysis
+type interval struct {
+	Index      int
+	Start, End string
+	HasScore   bool
+	Score      float64
+	Counts     map[string]int
+}
+
+// CountsString lists how many metrics got each classification, e.g. 3 PASS, 1 HIGH
+func (i interval) CountsString() string {
+	var parts []string
+	for _, classification := range sortedClassifications(i.Counts) {
+		parts = append(parts, fmt.Sprintf("%d %s", i.Counts[classification], classification))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Window is the time range the interval analyzed
+func (i interval) Window() string {
+	if i.Start == "" && i.End == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s - %s", i.Start, i.End)
+}
+
+func sortedClassifications(counts map[string]int) []string {
+	rank := func(classification string) int {
+		for i, c := range classificationOrder {
+			if c == classification {
+				return i
+			}
+		}
+		return len(classificationOrder)
+	}
+	var classifications []string
+	for classification := range counts {
+		classifications = append(classifications, classification)
+	}
+	sort.Slice(classifications, func(a, b int) bool {
+		ra, rb := rank(classifications[a]), rank(classifications[b])
+		if ra != rb {
+			return ra < rb
+		}
+		return classifications[a] < classifications[b]
+	})
+	return classifications
+}
+
+// intervals lists every canary run of the result in order
+func intervals(result kayenta.CanaryAnalysisExecutionResult) []interval {
+	var all []interval
+	for i, canaryResult := range result.CanaryExecutionResults {
+		iv := interval{Index: i + 1, Counts: map[string]int{}}
+		if i < len(result.CanaryScores) {
+			iv.HasScore = true
+			iv.Score = result.CanaryScores[i]
+		}
+		iv.Start, iv.End = window(canaryResult.CanaryExecutionRequest)
+		for _, metric := range canaryResult.Result.JudgeResult.Results {
+			iv.Counts[strings.ToUpper(metric.Classification)]++
+		}
+		all = append(all, iv)
+	}
+	return all
+}
+
+// window is the time range the experiment was analyzed over. with multiple
+// scopes, the scope with the first name is used
+func window(request *kayenta.CanaryExecutionRequest) (string, string) {
+	if request == nil || len(request.Scopes) == 0 {
+		return "", ""
+	}
+	var names []string
+	for name := range request.Scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	scope := request.Scopes[names[0]].ExperimentScope
+	return scope.Start, scope.End
+}
+
+// sparkline draws scores as a line of bars. scores are on a fixed scale of 0
+// to 100, so that sparklines of different analyses can be compared
+func sparkline(scores []float64) string {
+	var b strings.Builder
+	for _, score := range scores {
+		i := int(math.Round(clampScore(score) / 100 * float64(len(sparkChars)-1)))
+		b.WriteRune(sparkChars[i])
+	}
+	return b.String()
+}
+
+func tableFromIntervals(intervals []interval) string {
+	wb := new(bytes.Buffer)
+	table := tablewriter.NewWriter(wb)
+	table.SetHeader([]string{"Interval", "Window", "Score", "Classifications"})
+	table.SetAutoWrapText(false)
+
+	for _, iv := range intervals {
+		score := "-"
+		if iv.HasScore {
+			score = formatScore(iv.Score)
+		}
+		table.Append([]string{fmt.Sprint(iv.Index), iv.Window(), score, iv.CountsString()})
+	}
+	table.Render()
+	return wb.String()
+}
@@ -0,0 +1,32 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▅█▁█", sparkline([]float64{0, 50, 100, -10, 120}))
+	assert.Equal(t, "", sparkline(nil))
+}
+
+func TestIntervals(t *testing.T) {
+	result := testResult().CanaryAnalysisExecutionResult
+	result.CanaryExecutionResults[0].CanaryExecutionRequest = &kayenta.CanaryExecutionRequest{
+		Scopes: map[string]kayenta.CanaryScopePair{
+			"secondary": {ExperimentScope: kayenta.CanaryScope{Start: "2020-12-20T15:00:00Z", End: "2020-12-20T15:05:00Z"}},
+			"default":   {ExperimentScope: kayenta.CanaryScope{Start: "2020-12-20T14:00:00Z", End: "2020-12-20T14:05:00Z"}},
+		},
+	}
+
+	all := intervals(result)
+	assert.Len(t, all, 2)
+	assert.Equal(t, "2020-12-20T14:00:00Z - 2020-12-20T14:05:00Z", all[0].Window())
+	assert.Equal(t, "", all[0].CountsString())
+	assert.Equal(t, "-", all[1].Window())
+	assert.Equal(t, 62.5, all[1].Score)
+	assert.Equal(t, "1 PASS, 1 HIGH, 1 NODATA", all[1].CountsString())
+}
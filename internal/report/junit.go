@@ -0,0 +1,175 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// metrics that aren't in any group are reported in this suite
+const ungroupedSuite = "ungrouped"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// JUnitReport renders the metrics of the final canary run as JUnit XML so
+// that CI systems can display them. Each metric is a testcase in a suite per
+// metric group, and HIGH, LOW and NODATA classifications are failures.
+func JUnitReport(result kayenta.GetStandaloneCanaryAnalysisOutput) ([]byte, error) {
+	executionResult := result.CanaryAnalysisExecutionResult
+	thresholds := result.CanaryAnalysisExecutionRequest.Thresholds
+	properties := []junitProperty{
+		{Name: "executionId", Value: result.PipelineID},
+		{Name: "status", Value: result.ExecutionStatus},
+		{Name: "finalScore", Value: finalScore(executionResult.CanaryScores)},
+		{Name: "marginalThreshold", Value: thresholds.Marginal},
+		{Name: "passThreshold", Value: thresholds.Pass},
+	}
+
+	var suites []junitTestSuite
+	if len(executionResult.CanaryExecutionResults) == 0 {
+		// the execution failed before judging any metrics, a single errored
+		// testcase makes sure the failure doesn't go unnoticed in CI
+		suites = append(suites, junitTestSuite{
+			Name:       "analysis",
+			Properties: properties,
+			TestCases: []junitTestCase{{
+				Name:      "analysis",
+				ClassName: "analysis",
+				Error: &junitProblem{
+					Message: executionResult.CanaryScoreMessage,
+					Type:    result.ExecutionStatus,
+					Body:    executionResult.CanaryScoreMessage,
+				},
+			}},
+		})
+	} else {
+		last := executionResult.CanaryExecutionResults[len(executionResult.CanaryExecutionResults)-1]
+		suites = groupSuites(last.Result.JudgeResult, properties)
+	}
+
+	report := junitTestSuites{Name: fmt.Sprintf("Kayenta analysis %s", result.PipelineID)}
+	for i := range suites {
+		suite := &suites[i]
+		suite.Tests = len(suite.TestCases)
+		for _, tc := range suite.TestCases {
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+			if tc.Error != nil {
+				suite.Errors++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+	report.Suites = suites
+
+	b, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+// groupSuites creates a suite for every metric group, ordered by name. A
+// metric in more than one group appears in each of their suites.
+func groupSuites(judgeResult kayenta.JudgeResult, properties []junitProperty) []junitTestSuite {
+	groupScores := map[string]float64{}
+	for _, group := range judgeResult.GroupScores {
+		groupScores[group.Name] = group.Score
+	}
+
+	testCases := map[string][]junitTestCase{}
+	for _, metric := range judgeResult.Results {
+		groups := metric.Groups
+		if len(groups) == 0 {
+			groups = []string{ungroupedSuite}
+		}
+		for _, group := range groups {
+			testCases[group] = append(testCases[group], metricTestCase(metric, group))
+		}
+	}
+
+	var names []string
+	for name := range testCases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var suites []junitTestSuite
+	for _, name := range names {
+		suiteProperties := append([]junitProperty{}, properties...)
+		if score, ok := groupScores[name]; ok {
+			suiteProperties = append(suiteProperties, junitProperty{Name: "groupScore", Value: formatScore(score)})
+		}
+		suites = append(suites, junitTestSuite{Name: name, Properties: suiteProperties, TestCases: testCases[name]})
+	}
+	return suites
+}
+
+func metricTestCase(metric kayenta.MetricResult, group string) junitTestCase {
+	tc := junitTestCase{Name: metric.Name, ClassName: group}
+	classification := strings.ToUpper(metric.Classification)
+	reason := metric.ClassificationReason
+	if reason == "" {
+		reason = fmt.Sprintf("metric was classified as %s", classification)
+	}
+
+	switch classification {
+	case "HIGH", "LOW", "NODATA":
+		tc.Failure = &junitProblem{Message: reason, Type: classification, Body: reason}
+	case "ERROR":
+		tc.Error = &junitProblem{Message: reason, Type: classification, Body: reason}
+	}
+	return tc
+}
+
+func finalScore(scores []float64) string {
+	if len(scores) == 0 {
+		return ""
+	}
+	return formatScore(scores[len(scores)-1])
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitReport(t *testing.T) {
+	b, err := JUnitReport(testResult())
+	assert.Nil(t, err)
+
+	var report junitTestSuites
+	assert.Nil(t, xml.Unmarshal(b, &report))
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 3, report.Failures)
+	assert.Len(t, report.Suites, 2)
+
+	requests := report.Suites[0]
+	assert.Equal(t, "requests", requests.Name)
+	assert.Equal(t, 2, requests.Failures)
+	assert.Equal(t, "HIGH", requests.TestCases[0].Failure.Type)
+	assert.Equal(t, "errors increased", requests.TestCases[0].Failure.Message)
+	assert.Equal(t, "NODATA", requests.TestCases[1].Failure.Type)
+	assert.Contains(t, requests.Properties, junitProperty{Name: "finalScore", Value: "62.5"})
+	assert.Contains(t, requests.Properties, junitProperty{Name: "passThreshold", Value: "90"})
+	assert.Contains(t, requests.Properties, junitProperty{Name: "groupScore", Value: "0"})
+
+	system := report.Suites[1]
+	assert.Equal(t, "system", system.Name)
+	assert.Equal(t, 2, system.Tests)
+	assert.Nil(t, system.TestCases[0].Failure)
+}
+
+func TestJUnitReportWithoutResults(t *testing.T) {
+	result := kayenta.GetStandaloneCanaryAnalysisOutput{
+		ExecutionStatus: "TERMINAL",
+		Complete:        true,
+		CanaryAnalysisExecutionResult: kayenta.CanaryAnalysisExecutionResult{
+			CanaryScoreMessage: "metrics account not found",
+		},
+	}
+	b, err := JUnitReport(result)
+	assert.Nil(t, err)
+
+	var report junitTestSuites
+	assert.Nil(t, xml.Unmarshal(b, &report))
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, "metrics account not found", report.Suites[0].TestCases[0].Error.Message)
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/armory-io/kayentactl/internal/analysis"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+const markdownReport = `## Canary analysis {{ .ID }}
+
+![canary: {{ .Outcome }}](https://img.shields.io/badge/canary-{{ .Outcome }}-{{ .BadgeColor }})
+
+**Final score:** {{ .FinalScore }} (marginal: {{ .Thresholds.Marginal }}, pass: {{ .Thresholds.Pass }})
+{{- if .Message }}
+
+> {{ cell .Message }}
+{{- end }}
+{{- if .Groups }}
+
+### Group scores
+
+| Group | Score |
+|-------|------:|
+{{- range .Groups }}
+| {{ cell .Name }} | {{ score .Score }} |
+{{- end }}
+{{- end }}
+{{- if .Metrics }}
+
+### Metrics
+
+| Metric | Groups | Classification | Control mean | Experiment mean | Difference |
+|--------|--------|----------------|-------------:|----------------:|-----------:|
+{{- range .Metrics }}
+| {{ cell .Name }} | {{ cell (join .Groups ", ") }} | {{ icon .Classification }} {{ .Classification }} | {{ mean .Control }} | {{ mean .Experiment }} | {{ difference . }} |
+{{- end }}
+{{- range .Metrics }}
+{{- if failed .Classification }}
+
+<details>
+<summary>{{ icon .Classification }} {{ html .Name }}: {{ .Classification }}</summary>
+
+{{ reason . }}
+
+</details>
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Intervals }}
+
+### Intervals
+
+Score trend: {{ .Sparkline }}
+
+| Interval | Window | Score | Classifications |
+|---------:|--------|------:|-----------------|
+{{- range .Intervals }}
+| {{ .Index }} | {{ .Window }} | {{ if .HasScore }}{{ score .Score }}{{ else }}-{{ end }} | {{ .CountsString }} |
+{{- end }}
+{{- end }}
+`
+
+type markdownReportData struct {
+	ID         string
+	Outcome    analysis.Outcome
+	BadgeColor string
+	FinalScore string
+	Thresholds kayenta.Threshold
+	Message    string
+	Groups     []kayenta.MetricGroup
+	Metrics    []kayenta.MetricResult
+	Intervals  []interval
+	Sparkline  string
+}
+
+var badgeColors = map[analysis.Outcome]string{
+	analysis.OutcomePass:     "brightgreen",
+	analysis.OutcomeMarginal: "yellow",
+	analysis.OutcomeFail:     "red",
+	analysis.OutcomeError:    "red",
+	analysis.OutcomeRunning:  "blue",
+}
+
+var markdownFuncs = template.FuncMap{
+	"cell":       markdownCell,
+	"score":      formatScore,
+	"join":       strings.Join,
+	"icon":       classificationIcon,
+	"failed":     isFailedClassification,
+	"html":       template.HTMLEscapeString,
+	"mean":       formatMean,
+	"difference": formatDifference,
+	"reason": func(metric kayenta.MetricResult) string {
+		if metric.ClassificationReason == "" {
+			return "No reason was given for the classification."
+		}
+		return template.HTMLEscapeString(metric.ClassificationReason)
+	},
+}
+
+// markdownCell makes a value safe to put in a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func classificationIcon(classification string) string {
+	switch strings.ToUpper(classification) {
+	case "PASS":
+		return ":white_check_mark:"
+	case "HIGH", "LOW", "NODATA", "ERROR":
+		return ":x:"
+	default:
+		return ":grey_question:"
+	}
+}
+
+func isFailedClassification(classification string) bool {
+	switch strings.ToUpper(classification) {
+	case "HIGH", "LOW", "NODATA", "ERROR":
+		return true
+	}
+	return false
+}
+
+// MarkdownReport renders the result as GitHub flavored markdown, e.g. to post
+// it as a comment on a pull request
+func MarkdownReport(result kayenta.GetStandaloneCanaryAnalysisOutput) ([]byte, error) {
+	tmpl, err := template.New("markdownReport").Funcs(markdownFuncs).Parse(markdownReport)
+	if err != nil {
+		return nil, err
+	}
+
+	executionResult := result.CanaryAnalysisExecutionResult
+	outcome := analysis.ResultOutcome(result)
+	data := markdownReportData{
+		ID:         result.PipelineID,
+		Outcome:    outcome,
+		BadgeColor: badgeColors[outcome],
+		FinalScore: finalScore(executionResult.CanaryScores),
+		Thresholds: result.CanaryAnalysisExecutionRequest.Thresholds,
+		Message:    executionResult.CanaryScoreMessage,
+		Intervals:  intervals(executionResult),
+		Sparkline:  sparkline(executionResult.CanaryScores),
+	}
+	if data.FinalScore == "" {
+		data.FinalScore = "none"
+	}
+	if results := executionResult.CanaryExecutionResults; len(results) > 0 {
+		judgeResult := results[len(results)-1].Result.JudgeResult
+		data.Groups = judgeResult.GroupScores
+		data.Metrics = judgeResult.Results
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
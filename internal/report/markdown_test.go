@@ -0,0 +1,23 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownReport(t *testing.T) {
+	b, err := MarkdownReport(testResult())
+	assert.Nil(t, err)
+	md := string(b)
+
+	assert.Contains(t, md, "![canary: marginal](https://img.shields.io/badge/canary-marginal-yellow)")
+	assert.Contains(t, md, "**Final score:** 62.5 (marginal: 50, pass: 90)")
+	assert.Contains(t, md, "| system | 50 |")
+	assert.Contains(t, md, "| errors | requests, system | :x: High |")
+	assert.Contains(t, md, "| cpu | system | :white_check_mark: Pass |")
+	assert.Contains(t, md, "<summary>:x: errors: High</summary>\n\nerrors increased\n")
+	assert.NotContains(t, md, "<summary>:white_check_mark: cpu")
+	assert.Contains(t, md, "Score trend: █▅")
+	assert.Contains(t, md, "| 2 | - | 62.5 | 1 PASS, 1 HIGH, 1 NODATA |")
+}
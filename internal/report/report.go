@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/armory-io/kayentactl/pkg/kayenta"
 
@@ -34,14 +36,24 @@ Status: {{ .Status }}
 Final Score: {{ .FinalScore }}
 Message: {{ .Message }}
 HasWarnings: {{ .HasWarnings }}
+Score Trend: {{ .Sparkline }}
+
+Intervals
+{{ .Intervals }}
 
 Measurements
 {{ .Measurements }}
+{{- if .Statistics }}
+
+Metric Statistics
+{{ .Statistics }}
+{{- end }}
 
 Group Results
 {{ .Results }}
 
 Stage Results
+{{ .Stages }}
 `
 
 type asciiReportData struct {
@@ -52,6 +64,10 @@ type asciiReportData struct {
 	HasWarnings  bool
 	Results      string
 	Measurements string
+	Statistics   string
+	Sparkline    string
+	Intervals    string
+	Stages       string
 }
 
 func resultToAsciiReportData(result kayenta.GetStandaloneCanaryAnalysisOutput) (asciiReportData, error) {
@@ -76,6 +92,8 @@ func resultToAsciiReportData(result kayenta.GetStandaloneCanaryAnalysisOutput) (
 		}
 		reportData.Results = resultsTable
 		reportData.Measurements = measurementsTables
+		reportData.Statistics = tableFromStatistics(lastResult.Result.JudgeResult)
+		reportData.Intervals = tableFromIntervals(intervals(result.CanaryAnalysisExecutionResult))
 	}
 
 	execStatus := color.GreenString(result.ExecutionStatus)
@@ -91,6 +109,7 @@ func resultToAsciiReportData(result kayenta.GetStandaloneCanaryAnalysisOutput) (
 		execStatus = color.YellowString(result.ExecutionStatus)
 	}
 	reportData.FinalScore = scoreStr
+	reportData.Sparkline = sparkline(scores)
 	reportData.Message = finalMsg
 
 	if result.ExecutionStatus == "TERMINAL" {
@@ -98,6 +117,7 @@ func resultToAsciiReportData(result kayenta.GetStandaloneCanaryAnalysisOutput) (
 	}
 
 	reportData.Status = execStatus
+	reportData.Stages = tableFromStages(result.Stages)
 	return reportData, nil
 }
 
@@ -135,6 +155,35 @@ func tableFromMeasurements(result kayenta.JudgeResult) (string, error) {
 	return wb.String(), nil
 }
 
+func tableFromStages(stages []kayenta.StageStatus) string {
+	wb := new(bytes.Buffer)
+	table := tablewriter.NewWriter(wb)
+	table.SetHeader([]string{"Name", "Type", "Status", "Duration", "Error"})
+	table.SetAutoWrapText(false)
+
+	for _, stage := range stages {
+		statusColor := tablewriter.Colors{tablewriter.Bold, tablewriter.FgWhiteColor}
+		switch stage.Status {
+		case "SUCCEEDED":
+			statusColor = tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor}
+		case "TERMINAL", "CANCELED":
+			statusColor = tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor}
+		}
+		duration := "-"
+		if d := stage.Duration(); d > 0 {
+			duration = d.Round(time.Second).String()
+		}
+		errorColor := tablewriter.Colors{}
+		if stage.ErrorMessage() != "" {
+			errorColor = tablewriter.Colors{tablewriter.FgYellowColor}
+		}
+		r := []string{stage.Name, stage.StageType, stage.Status, duration, stage.ErrorMessage()}
+		table.Rich(r, []tablewriter.Colors{{}, {}, statusColor, {}, errorColor})
+	}
+	table.Render()
+	return wb.String()
+}
+
 func TableReport(result kayenta.GetStandaloneCanaryAnalysisOutput) ([]byte, error) {
 	tmpl, err := template.New("asciiReport").Parse(asciiReport)
 	if err != nil {
@@ -155,18 +204,45 @@ func JsonReport(result kayenta.GetStandaloneCanaryAnalysisOutput) ([]byte, error
 	return json.MarshalIndent(result, "", "  ")
 }
 
-func Report(result kayenta.GetStandaloneCanaryAnalysisOutput, format string, writer io.Writer) error {
-	if !result.Complete && format != "json" {
+// Options are settings that only some formats use
+type Options struct {
+	// TemplateFile is the Go template rendered by the template format
+	TemplateFile string
+}
+
+func WithTemplateFile(file string) func(o *Options) {
+	return func(o *Options) {
+		o.TemplateFile = file
+	}
+}
+
+func Report(result kayenta.GetStandaloneCanaryAnalysisOutput, format string, writer io.Writer, opts ...func(o *Options)) error {
+	if !result.Complete && format != "json" && format != "template" {
 		return ErrNotComplete
 	}
 
+	var options Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	var b []byte
 	var err error
 	switch format {
 	case "json":
 		b, err = JsonReport(result)
-	default:
+	case "junit":
+		b, err = JUnitReport(result)
+	case "markdown":
+		b, err = MarkdownReport(result)
+	case "html":
+		b, err = HTMLReport(result)
+	case "template":
+		b, err = TemplateReport(result, options.TemplateFile)
+	case "pretty":
 		b, err = TableReport(result)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
 	}
 	if err != nil {
 		return err
@@ -0,0 +1,78 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+// testResult is a failed execution with a passing metric, a failing one and
+// one without data. the tests of every report format share it
+func testResult() kayenta.GetStandaloneCanaryAnalysisOutput {
+	return kayenta.GetStandaloneCanaryAnalysisOutput{
+		PipelineID:      "some-id",
+		ExecutionStatus: "TERMINAL",
+		Complete:        true,
+		CanaryAnalysisExecutionResult: kayenta.CanaryAnalysisExecutionResult{
+			CanaryScores: []float64{100, 62.5},
+			CanaryExecutionResults: []kayenta.CanaryExecutionResult{
+				{},
+				{Result: kayenta.CanaryResult{JudgeResult: kayenta.JudgeResult{
+					Results: []kayenta.MetricResult{
+						{Name: "cpu", Classification: "Pass", Groups: []string{"system"}},
+						{Name: "errors", Classification: "High", ClassificationReason: "errors increased", Groups: []string{"requests", "system"}},
+						{Name: "latency", Classification: "Nodata", Groups: []string{"requests"}},
+					},
+					GroupScores: []kayenta.MetricGroup{{Name: "requests", Score: 0}, {Name: "system", Score: 50}},
+				}}},
+			},
+		},
+		CanaryAnalysisExecutionRequest: kayenta.ExecutionRequest{
+			Thresholds: kayenta.Threshold{Marginal: "50", Pass: "90"},
+		},
+	}
+}
+
+func TestTableReportStageResults(t *testing.T) {
+	color.NoColor = true
+	result := testResult()
+	result.Stages = []kayenta.StageStatus{
+		{StageType: "runCanary", Name: "Run Canary #1", Status: "SUCCEEDED", StartTime: 1600000000000, EndTime: 1600000061400},
+		{StageType: "runCanary", Name: "Run Canary #2", Status: "TERMINAL", StartTime: 1600000061400, EndTime: 1600000062000,
+			Context: kayenta.StageContext{Exception: &kayenta.StageException{Details: kayenta.StageExceptionDetails{Error: "metrics account not found"}}}},
+		{StageType: "generateCanaryAnalysisResult", Name: "Generate Canary Analysis Result", Status: "NOT_STARTED"},
+	}
+
+	b, err := TableReport(result)
+	assert.Nil(t, err)
+	stages := string(b)[strings.Index(string(b), "Stage Results"):]
+
+	assert.Regexp(t, `Run Canary #1 +\| runCanary +\| .*SUCCEEDED.* +\| 1m1s +\|`, stages)
+	assert.Regexp(t, `Run Canary #2 +\| runCanary +\| .*TERMINAL.* +\| 1s +\| .*metrics account not found`, stages)
+	assert.Regexp(t, `Generate Canary Analysis Result +\| generateCanaryAnalysisResult +\| .*NOT_STARTED.* +\| - +\|`, stages)
+}
+
+func TestTableReportStatistics(t *testing.T) {
+	color.NoColor = true
+	median := kayenta.Float(12)
+	result := testResult()
+	metrics := result.CanaryAnalysisExecutionResult.CanaryExecutionResults[1].Result.JudgeResult.Results
+	metrics[1].ControlMetadata = &kayenta.MetricMetadata{Stats: &kayenta.MetricStatistics{Count: 10, Mean: 10, Min: 8, Max: 12.345, Median: &median}}
+	metrics[1].ExperimentMetadata = &kayenta.MetricMetadata{Stats: &kayenta.MetricStatistics{Count: 9, Mean: 15, Min: 9, Max: 20}}
+
+	b, err := TableReport(result)
+	assert.Nil(t, err)
+	report := string(b)
+
+	assert.Contains(t, report, "Metric Statistics")
+	assert.Regexp(t, `errors +\| 10 / 9 +\| 10 / 15 +\| 12 / - +\| 8 / 9 +\| 12.35 / 20 +\| \+50.0% +\|`, report)
+	assert.Regexp(t, `cpu +\| - / - +\| - / - +\| - / - +\| - / - +\| - / - +\| - +\|`, report)
+
+	b, err = TableReport(testResult())
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "Metric Statistics")
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/olekukonko/tablewriter"
+)
+
+// formatStat formats a statistic with at most 2 decimals, or - if it's missing
+func formatStat(v *kayenta.Float) string {
+	if v == nil || math.IsNaN(float64(*v)) {
+		return "-"
+	}
+	return strconv.FormatFloat(math.Round(float64(*v)*100)/100, 'f', -1, 64)
+}
+
+// formatMean formats the mean of statistics that may be missing
+func formatMean(stats *kayenta.MetricStatistics) string {
+	if stats == nil {
+		return "-"
+	}
+	return formatStat(&stats.Mean)
+}
+
+// formatDifference formats the relative difference of a metric, e.g. +50.0%
+func formatDifference(metric kayenta.MetricResult) string {
+	difference, ok := metric.RelativeDifference()
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", difference)
+}
+
+// controlVsExperiment formats a statistic of the control and the experiment
+// as a single cell, e.g. 1.2 / 1.5
+func controlVsExperiment(metric kayenta.MetricResult, stat func(s *kayenta.MetricStatistics) *kayenta.Float) string {
+	control, experiment := "-", "-"
+	if metric.Control() != nil {
+		control = formatStat(stat(metric.Control()))
+	}
+	if metric.Experiment() != nil {
+		experiment = formatStat(stat(metric.Experiment()))
+	}
+	return control + " / " + experiment
+}
+
+func hasStatistics(results []kayenta.MetricResult) bool {
+	for _, metric := range results {
+		if metric.Control() != nil || metric.Experiment() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func tableFromStatistics(result kayenta.JudgeResult) string {
+	if !hasStatistics(result.Results) {
+		return ""
+	}
+
+	wb := new(bytes.Buffer)
+	table := tablewriter.NewWriter(wb)
+	table.SetHeader([]string{"Name", "Count", "Mean", "Median", "Min", "Max", "Difference"})
+	table.SetAutoWrapText(false)
+
+	for _, metric := range result.Results {
+		count := "- / -"
+		if metric.Control() != nil && metric.Experiment() != nil {
+			count = fmt.Sprintf("%d / %d", metric.Control().Count, metric.Experiment().Count)
+		}
+		table.Append([]string{
+			metric.Name,
+			count,
+			controlVsExperiment(metric, func(s *kayenta.MetricStatistics) *kayenta.Float { return &s.Mean }),
+			controlVsExperiment(metric, func(s *kayenta.MetricStatistics) *kayenta.Float { return s.Median }),
+			controlVsExperiment(metric, func(s *kayenta.MetricStatistics) *kayenta.Float { return &s.Min }),
+			controlVsExperiment(metric, func(s *kayenta.MetricStatistics) *kayenta.Float { return &s.Max }),
+			formatDifference(metric),
+		})
+	}
+	table.Render()
+	return "Values are control / experiment\n" + wb.String()
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/fatih/color"
+)
+
+var ErrNoTemplate = errors.New("the template format requires a template file")
+
+// TemplateFuncs are the helper functions available to user supplied report
+// templates. Like View, they are part of kayentactl's public interface.
+var TemplateFuncs = template.FuncMap{
+	// colors, which are disabled when output isn't a terminal or --no-color is set
+	"red":    color.RedString,
+	"green":  color.GreenString,
+	"yellow": color.YellowString,
+	"blue":   color.BlueString,
+	"bold":   func(s string) string { return color.New(color.Bold).Sprint(s) },
+	// colorize colors an outcome or a classification, e.g. passed and PASS are green
+	"colorize": colorize,
+
+	// percent formats a score, e.g. 62.5 becomes 62.5%
+	"percent": func(score float64) string { return formatScore(score) + "%" },
+	// ratio formats part as a percentage of total, e.g. 1 and 4 become 25%
+	"ratio": func(part, total int) string {
+		if total == 0 {
+			return "0%"
+		}
+		return strconv.FormatFloat(float64(part)*100/float64(total), 'f', 1, 64) + "%"
+	},
+	// score formats a score without trailing zeros
+	"score": formatScore,
+	// sparkline draws scores as bars, e.g. {{ sparkline .Summary.Scores }}
+	"sparkline": sparkline,
+
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func colorize(s string) string {
+	switch strings.ToLower(s) {
+	case "passed", "pass", "succeeded":
+		return color.GreenString(s)
+	case "marginal", "running":
+		return color.YellowString(s)
+	case "failed", "error", "terminal", "canceled", "high", "low", "nodata":
+		return color.RedString(s)
+	}
+	return s
+}
+
+// TemplateReport renders the Go template in file against the View of the result
+func TemplateReport(result kayenta.GetStandaloneCanaryAnalysisOutput, file string) ([]byte, error) {
+	if file == "" {
+		return nil, ErrNoTemplate
+	}
+	tmpl, err := template.New(filepath.Base(file)).Funcs(TemplateFuncs).ParseFiles(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse report template: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, NewView(result)); err != nil {
+		return nil, fmt.Errorf("could not render report template: %w", err)
+	}
+	return b.Bytes(), nil
+}
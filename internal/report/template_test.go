@@ -0,0 +1,49 @@
+package report
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTemplate = `{{ .Summary.ID }} {{ .Summary.Outcome }} {{ percent .Summary.FinalScore }} of {{ .Summary.Thresholds.Pass }}
+{{ range .Groups }}{{ .Name }}={{ score .Score }} {{ end }}
+{{ range .Metrics }}{{ if .Failed }}{{ .Name }} [{{ join .Groups "," }}] {{ lower .Classification }}
+{{ end }}{{ end -}}
+{{ range .Intervals }}{{ .Index }}:{{ .Score }} {{ end }}
+`
+
+func TestTemplateReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.tmpl")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(testTemplate), 0644))
+
+	b, err := TemplateReport(testResult(), path)
+	assert.Nil(t, err)
+	expected := `some-id marginal 62.5% of 90
+requests=0 system=50 
+errors [requests,system] high
+latency [requests] nodata
+1:100 2:62.5 
+`
+	assert.Equal(t, expected, string(b))
+
+	_, err = TemplateReport(testResult(), "")
+	assert.Equal(t, ErrNoTemplate, err)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	color.NoColor = true
+	ratio := TemplateFuncs["ratio"].(func(int, int) string)
+	colorize := TemplateFuncs["colorize"].(func(string) string)
+
+	assert.Equal(t, "25.0%", ratio(1, 4))
+	assert.Equal(t, "0%", ratio(1, 0))
+	assert.Equal(t, "HIGH", colorize("HIGH"))
+}
@@ -0,0 +1,185 @@
+package report
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armory-io/kayentactl/internal/analysis"
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+// View is the model that user supplied report templates are rendered against.
+// It is part of kayentactl's public interface: fields may be added, but
+// existing fields are never renamed, removed or change meaning.
+type View struct {
+	Summary   SummaryView
+	Groups    []GroupView
+	Metrics   []MetricView
+	Intervals []IntervalView
+	Stages    []StageView
+}
+
+// SummaryView describes the execution as a whole
+type SummaryView struct {
+	ID string
+	// Status is the execution status reported by kayenta, e.g. SUCCEEDED or TERMINAL
+	Status string
+	// Outcome is one of passed, marginal, failed, error or running
+	Outcome  string
+	Complete bool
+	// HasScore is false if the execution ended before producing a score
+	HasScore   bool
+	FinalScore float64
+	// Scores is the score of every interval
+	Scores      []float64
+	Message     string
+	HasWarnings bool
+	Thresholds  ThresholdsView
+}
+
+type ThresholdsView struct {
+	Marginal float64
+	Pass     float64
+}
+
+// GroupView is the score of a metric group in the final interval
+type GroupView struct {
+	Name  string
+	Score float64
+}
+
+// MetricView is how a metric was classified in the final interval
+type MetricView struct {
+	Name   string
+	Groups []string
+	// Classification is upper case, e.g. PASS, HIGH, LOW or NODATA
+	Classification string
+	Reason         string
+	// Failed is true for any classification but PASS
+	Failed bool
+	// Control and Experiment are nil if the judge didn't report statistics
+	Control, Experiment *StatisticsView
+	// Difference is how much the experiment's mean differs from the control's,
+	// as a percentage of the control's mean. It is only set if HasDifference is true.
+	HasDifference bool
+	Difference    float64
+}
+
+// StatisticsView summarizes the values of a metric. Missing values are NaN.
+type StatisticsView struct {
+	Count                  int
+	Mean, Median, Min, Max float64
+}
+
+// IntervalView is the result of one canary run
+type IntervalView struct {
+	// Index starts at 1
+	Index int
+	// Start and End are the ISO 8601 time window the interval analyzed, if known
+	Start, End string
+	Score      float64
+	Metrics    []MetricView
+	// Counts is the number of metrics with each classification, e.g. PASS
+	Counts map[string]int
+}
+
+type StageView struct {
+	Name   string
+	Type   string
+	Status string
+	// Duration is zero if the stage hasn't ended
+	Duration time.Duration
+	// Error is the message of the exception the stage failed with, if any
+	Error string
+}
+
+// NewView builds the view of a result
+func NewView(result kayenta.GetStandaloneCanaryAnalysisOutput) View {
+	executionResult := result.CanaryAnalysisExecutionResult
+	thresholds := result.CanaryAnalysisExecutionRequest.Thresholds
+	view := View{
+		Summary: SummaryView{
+			ID:          result.PipelineID,
+			Status:      result.ExecutionStatus,
+			Outcome:     string(analysis.ResultOutcome(result)),
+			Complete:    result.Complete,
+			HasScore:    len(executionResult.CanaryScores) > 0,
+			Scores:      executionResult.CanaryScores,
+			Message:     executionResult.CanaryScoreMessage,
+			HasWarnings: executionResult.HasWarnings,
+		},
+	}
+	view.Summary.Thresholds.Marginal, _ = strconv.ParseFloat(thresholds.Marginal, 64)
+	view.Summary.Thresholds.Pass, _ = strconv.ParseFloat(thresholds.Pass, 64)
+	if view.Summary.HasScore {
+		view.Summary.FinalScore = executionResult.CanaryScores[len(executionResult.CanaryScores)-1]
+	}
+
+	for i, iv := range intervals(executionResult) {
+		view.Intervals = append(view.Intervals, IntervalView{
+			Index:   iv.Index,
+			Start:   iv.Start,
+			End:     iv.End,
+			Score:   iv.Score,
+			Metrics: metricViews(executionResult.CanaryExecutionResults[i].Result.JudgeResult.Results),
+			Counts:  iv.Counts,
+		})
+	}
+	if len(view.Intervals) > 0 {
+		last := executionResult.CanaryExecutionResults[len(executionResult.CanaryExecutionResults)-1]
+		for _, group := range last.Result.JudgeResult.GroupScores {
+			view.Groups = append(view.Groups, GroupView{Name: group.Name, Score: group.Score})
+		}
+		view.Metrics = view.Intervals[len(view.Intervals)-1].Metrics
+	}
+
+	for _, stage := range result.Stages {
+		view.Stages = append(view.Stages, StageView{
+			Name:     stage.Name,
+			Type:     stage.StageType,
+			Status:   stage.Status,
+			Duration: stage.Duration(),
+			Error:    stage.ErrorMessage(),
+		})
+	}
+	return view
+}
+
+func metricViews(results []kayenta.MetricResult) []MetricView {
+	var metrics []MetricView
+	for _, result := range results {
+		classification := strings.ToUpper(result.Classification)
+		difference, hasDifference := result.RelativeDifference()
+		metrics = append(metrics, MetricView{
+			Name:           result.Name,
+			Groups:         result.Groups,
+			Classification: classification,
+			Reason:         result.ClassificationReason,
+			Failed:         classification != "PASS",
+			Control:        statisticsView(result.Control()),
+			Experiment:     statisticsView(result.Experiment()),
+			HasDifference:  hasDifference,
+			Difference:     difference,
+		})
+	}
+	return metrics
+}
+
+func statisticsView(stats *kayenta.MetricStatistics) *StatisticsView {
+	if stats == nil {
+		return nil
+	}
+	view := &StatisticsView{
+		Count:  stats.Count,
+		Mean:   float64(stats.Mean),
+		Median: math.NaN(),
+		Min:    float64(stats.Min),
+		Max:    float64(stats.Max),
+	}
+	if stats.Median != nil {
+		view.Median = float64(*stats.Median)
+	}
+	return view
+}
@@ -0,0 +1,225 @@
+package kayenta
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to every request the DefaultClient makes to
+// kayenta. This is needed when kayenta sits behind an authenticating proxy.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Invalidator is implemented by Authenticators that cache credentials. If kayenta
+// rejects a request with a 401, Invalidate is called and the request is sent
+// once more with fresh credentials.
+type Invalidator interface {
+	Invalidate()
+}
+
+// BasicAuth authenticates requests using HTTP basic auth
+type BasicAuth struct {
+	Username, Password string
+}
+
+func (b BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// TokenSource provides a bearer token
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// BearerToken authenticates requests with a static bearer token. It is not an
+// Invalidator, since sending the same token again after a 401 can't succeed.
+type BearerToken string
+
+func (t BearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// FileTokenSource reads a token from a file, e.g. one that is kept up to date
+// by a sidecar or an external login tool
+type FileTokenSource struct {
+	Path string
+}
+
+func (f FileTokenSource) Token(ctx context.Context) (string, error) {
+	b, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("could not read token file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// CommandTokenSource runs a command and uses whatever it prints to stdout as
+// the token, e.g. `gcloud auth print-identity-token`
+type CommandTokenSource struct {
+	Command []string
+}
+
+func (c CommandTokenSource) Token(ctx context.Context) (string, error) {
+	if len(c.Command) == 0 {
+		return "", errors.New("no token command configured")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("token command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// tokenExpiryLeeway refreshes tokens slightly before they expire so that they
+// don't expire while a request is in flight
+const tokenExpiryLeeway = 30 * time.Second
+
+// BearerTokenAuth authenticates requests with a bearer token from a TokenSource.
+// Tokens are cached until they expire. If the token is a JWT, its exp claim is
+// used as the expiry, otherwise the token is kept for TTL. A TTL of 0 caches
+// non-JWT tokens until kayenta rejects them.
+type BearerTokenAuth struct {
+	Source TokenSource
+	TTL    time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func NewBearerTokenAuth(source TokenSource, ttl time.Duration) *BearerTokenAuth {
+	return &BearerTokenAuth{Source: source, TTL: ttl}
+}
+
+func (b *BearerTokenAuth) Authenticate(req *http.Request) error {
+	token, err := b.currentToken(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (b *BearerTokenAuth) Invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.token = ""
+}
+
+func (b *BearerTokenAuth) currentToken(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.token != "" && (b.expiry.IsZero() || time.Now().Add(tokenExpiryLeeway).Before(b.expiry)) {
+		return b.token, nil
+	}
+
+	token, err := b.Source.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not get bearer token: %w", err)
+	}
+	if token == "" {
+		return "", errors.New("could not get bearer token: token is empty")
+	}
+
+	b.token = token
+	b.expiry = time.Time{}
+	if exp, ok := jwtExpiry(token); ok {
+		b.expiry = exp
+	} else if b.TTL > 0 {
+		b.expiry = time.Now().Add(b.TTL)
+	}
+	return b.token, nil
+}
+
+// jwtExpiry returns the exp claim of a JWT. the signature is not verified since
+// we only use it to decide when to fetch a new token.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// LoadTLSConfig creates a tls.Config that presents the given client certificate
+// (for mTLS) and trusts the given CA bundle in addition to the system roots.
+// Any of the paths may be empty.
+func LoadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, errors.New("both a client certificate and key are required for mTLS")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+func ClientAuthenticator(auth Authenticator) func(dc *DefaultClient) {
+	return func(dc *DefaultClient) {
+		dc.Authenticator = auth
+	}
+}
+
+// ClientTLSConfig replaces the client's HTTPClientFactory with one whose
+// transport uses the given TLS configuration
+func ClientTLSConfig(cfg *tls.Config) func(dc *DefaultClient) {
+	return func(dc *DefaultClient) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg
+		client := &http.Client{Transport: transport}
+		dc.ClientFactory = func() *http.Client {
+			return client
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package kayenta
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTokenSource struct {
+	tokens []string
+	calls  int
+}
+
+func (c *countingTokenSource) Token(ctx context.Context) (string, error) {
+	token := c.tokens[c.calls]
+	c.calls++
+	return token, nil
+}
+
+func TestBearerTokenIsRefreshedWhenRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"name": "metrics"}]`))
+	}))
+	defer server.Close()
+
+	source := &countingTokenSource{tokens: []string{"stale", "fresh"}}
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientAuthenticator(NewBearerTokenAuth(source, time.Hour)))
+
+	creds, err := c.GetCredentials(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []AccountCredential{{Name: "metrics"}}, creds)
+
+	_, err = c.GetCredentials(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, source.calls)
+}
+
+func TestBearerTokenIsNotRetriedWhenRejected(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer static", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientAuthenticator(BearerToken("static")))
+	_, err := c.GetCredentials(context.Background())
+	assert.Equal(t, http.StatusUnauthorized, err.(ServerError).Code)
+	assert.Equal(t, 1, requests)
+}
+
+func TestBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientAuthenticator(BasicAuth{Username: "user", Password: "pass"}))
+	_, err := c.GetCredentials(context.Background())
+	assert.Nil(t, err)
+}
+
+func TestJWTExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp": %d}`, 1700000000)))
+	exp, ok := jwtExpiry("header." + payload + ".signature")
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1700000000, 0), exp)
+
+	_, ok = jwtExpiry("not-a-jwt")
+	assert.False(t, ok)
+}
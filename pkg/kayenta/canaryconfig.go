@@ -0,0 +1,357 @@
+package kayenta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CanaryConfig mirrors Kayenta's canary config schema. Every field Kayenta
+// knows about is modeled so that configs read from a file or from Kayenta can
+// be sent back to Kayenta without losing anything. Fields that aren't modeled,
+// e.g. ones added by newer versions of Kayenta, are kept in Other.
+type CanaryConfig struct {
+	Id                  string            `json:"id,omitempty"`
+	Name                string            `json:"name"`
+	Description         string            `json:"description,omitempty"`
+	Applications        []string          `json:"applications,omitempty"`
+	ConfigVersion       string            `json:"configVersion,omitempty"`
+	CreatedTimestamp    int64             `json:"createdTimestamp,omitempty"`
+	CreatedTimestampIso string            `json:"createdTimestampIso,omitempty"`
+	UpdatedTimestamp    int64             `json:"updatedTimestamp,omitempty"`
+	UpdatedTimestampIso string            `json:"updatedTimestampIso,omitempty"`
+	Judge               JudgeConfig       `json:"judge"`
+	Metrics             []Metric          `json:"metrics"`
+	Templates           map[string]string `json:"templates,omitempty"`
+	Classifier          CanaryClassifier  `json:"classifier"`
+
+	// Other holds the fields kayentactl doesn't model, which are written back as-is
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (cc CanaryConfig) MarshalJSON() ([]byte, error) {
+	type plain CanaryConfig
+	return marshalWithOther(plain(cc), cc.Other)
+}
+
+func (cc *CanaryConfig) UnmarshalJSON(b []byte) error {
+	type plain CanaryConfig
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*cc = CanaryConfig(p)
+	cc.Other = other
+	return nil
+}
+
+type JudgeConfig struct {
+	Name                string                 `json:"name"`
+	JudgeConfigurations map[string]interface{} `json:"judgeConfigurations,omitempty"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (j JudgeConfig) MarshalJSON() ([]byte, error) {
+	type plain JudgeConfig
+	return marshalWithOther(plain(j), j.Other)
+}
+
+func (j *JudgeConfig) UnmarshalJSON(b []byte) error {
+	type plain JudgeConfig
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*j = JudgeConfig(p)
+	j.Other = other
+	return nil
+}
+
+type Metric struct {
+	Groups    []string    `json:"groups"`
+	Name      string      `json:"name"`
+	Query     MetricQuery `json:"query"`
+	ScopeName string      `json:"scopeName"`
+
+	AnalysisConfigurations AnalysisConfiguration `json:"analysisConfigurations"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (m Metric) MarshalJSON() ([]byte, error) {
+	type plain Metric
+	return marshalWithOther(plain(m), m.Other)
+}
+
+func (m *Metric) UnmarshalJSON(b []byte) error {
+	type plain Metric
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*m = Metric(p)
+	m.Other = other
+	return nil
+}
+
+// Validate checks that every metric has a name and a valid query for its
+// metrics provider
+func (cc CanaryConfig) Validate() error {
+	if len(cc.Metrics) == 0 {
+		return errors.New("canary config has no metrics")
+	}
+	for i, metric := range cc.Metrics {
+		if metric.Name == "" {
+			return fmt.Errorf("metric %d has no name", i+1)
+		}
+		if metric.Query.Query == nil {
+			return fmt.Errorf("metric %q has no query", metric.Name)
+		}
+		if err := metric.Query.Validate(); err != nil {
+			return fmt.Errorf("metric %q has an invalid query: %w", metric.Name, err)
+		}
+	}
+	return nil
+}
+
+// AnalysisConfiguration holds per-judge settings for a metric. The settings
+// for the NetflixACAJudge, which live under the "canary" key, are typed.
+// Settings for any other judge are kept as-is in Other.
+type AnalysisConfiguration struct {
+	Canary *CanaryAnalysisConfiguration
+	Other  map[string]json.RawMessage
+}
+
+func (a AnalysisConfiguration) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{}
+	for k, v := range a.Other {
+		m[k] = v
+	}
+	if a.Canary != nil {
+		m["canary"] = a.Canary
+	}
+	return json.Marshal(m)
+}
+
+func (a *AnalysisConfiguration) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	*a = AnalysisConfiguration{}
+	if raw, ok := m["canary"]; ok {
+		var canary CanaryAnalysisConfiguration
+		if err := json.Unmarshal(raw, &canary); err != nil {
+			return err
+		}
+		a.Canary = &canary
+		delete(m, "canary")
+	}
+	if len(m) > 0 {
+		a.Other = m
+	}
+	return nil
+}
+
+// Direction is the direction of change in a metric that is considered a failure
+type Direction string
+
+const (
+	DirectionIncrease Direction = "increase"
+	DirectionDecrease Direction = "decrease"
+	DirectionEither   Direction = "either"
+)
+
+// NanStrategy determines what happens to missing data points in a metric
+type NanStrategy string
+
+const (
+	NanStrategyRemove  NanStrategy = "remove"
+	NanStrategyReplace NanStrategy = "replace"
+)
+
+// OutlierStrategy determines whether outliers are removed before judging a metric
+type OutlierStrategy string
+
+const (
+	OutlierStrategyRemove OutlierStrategy = "remove"
+	OutlierStrategyKeep   OutlierStrategy = "keep"
+)
+
+// CanaryAnalysisConfiguration configures how the NetflixACAJudge classifies a metric
+type CanaryAnalysisConfiguration struct {
+	Direction    Direction         `json:"direction,omitempty"`
+	NanStrategy  NanStrategy       `json:"nanStrategy,omitempty"`
+	Critical     bool              `json:"critical,omitempty"`
+	MustHaveData bool              `json:"mustHaveData,omitempty"`
+	EffectSize   *EffectSize       `json:"effectSize,omitempty"`
+	Outliers     *OutlierDetection `json:"outliers,omitempty"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (c CanaryAnalysisConfiguration) MarshalJSON() ([]byte, error) {
+	type plain CanaryAnalysisConfiguration
+	return marshalWithOther(plain(c), c.Other)
+}
+
+func (c *CanaryAnalysisConfiguration) UnmarshalJSON(b []byte) error {
+	type plain CanaryAnalysisConfiguration
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*c = CanaryAnalysisConfiguration(p)
+	c.Other = other
+	return nil
+}
+
+// EffectSize is how large a difference between the control and experiment
+// must be before the metric is classified as HIGH or LOW. The sizes are
+// pointers so that an explicit 0 is told apart from kayenta's default.
+type EffectSize struct {
+	AllowedIncrease  *float64 `json:"allowedIncrease,omitempty"`
+	AllowedDecrease  *float64 `json:"allowedDecrease,omitempty"`
+	CriticalIncrease *float64 `json:"criticalIncrease,omitempty"`
+	CriticalDecrease *float64 `json:"criticalDecrease,omitempty"`
+	// Measure is either meanRatio (the default) or cles
+	Measure string `json:"measure,omitempty"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (e EffectSize) MarshalJSON() ([]byte, error) {
+	type plain EffectSize
+	return marshalWithOther(plain(e), e.Other)
+}
+
+func (e *EffectSize) UnmarshalJSON(b []byte) error {
+	type plain EffectSize
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*e = EffectSize(p)
+	e.Other = other
+	return nil
+}
+
+type OutlierDetection struct {
+	Strategy OutlierStrategy `json:"strategy,omitempty"`
+	// OutlierFactor is a pointer so that an explicit 0 is kept
+	OutlierFactor *float64 `json:"outlierFactor,omitempty"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (o OutlierDetection) MarshalJSON() ([]byte, error) {
+	type plain OutlierDetection
+	return marshalWithOther(plain(o), o.Other)
+}
+
+func (o *OutlierDetection) UnmarshalJSON(b []byte) error {
+	type plain OutlierDetection
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*o = OutlierDetection(p)
+	o.Other = other
+	return nil
+}
+
+// CanaryClassifier weighs the scores of metric groups into the canary score.
+// Settings it doesn't model, like scoreThresholds, are kept in Other.
+type CanaryClassifier struct {
+	GroupWeights map[string]float64 `json:"groupWeights"`
+
+	Other map[string]json.RawMessage `json:"-"`
+}
+
+func (c CanaryClassifier) MarshalJSON() ([]byte, error) {
+	type plain CanaryClassifier
+	return marshalWithOther(plain(c), c.Other)
+}
+
+func (c *CanaryClassifier) UnmarshalJSON(b []byte) error {
+	type plain CanaryClassifier
+	var p plain
+	other, err := unmarshalWithOther(b, &p)
+	if err != nil {
+		return err
+	}
+	*c = CanaryClassifier(p)
+	c.Other = other
+	return nil
+}
+
+// marshalWithOther marshals v, a struct, along with the fields in other that
+// it has no field for
+func marshalWithOther(v interface{}, other map[string]json.RawMessage) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(other) == 0 {
+		return b, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for k, raw := range other {
+		if _, ok := fields[k]; !ok {
+			fields[k] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// unmarshalWithOther unmarshals b into v, a pointer to a struct, and returns
+// the fields of b that v has no field for
+func unmarshalWithOther(b []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range jsonFieldNames(reflect.TypeOf(v).Elem()) {
+		for k := range fields {
+			// encoding/json matches field names case-insensitively
+			if strings.EqualFold(k, name) {
+				delete(fields, k)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// jsonFieldNames lists the names the fields of struct type t have in JSON
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
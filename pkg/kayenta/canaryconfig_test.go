@@ -0,0 +1,123 @@
+package kayenta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fullCanaryConfig = `{
+  "id": "abc",
+  "name": "full",
+  "description": "every field kayenta knows about",
+  "applications": ["app"],
+  "configVersion": "1",
+  "createdTimestamp": 1610000000000,
+  "createdTimestampIso": "2021-01-07T06:13:20Z",
+  "updatedTimestamp": 1610000001000,
+  "updatedTimestampIso": "2021-01-07T06:13:21Z",
+  "judge": {"name": "NetflixACAJudge-v1.0", "judgeConfigurations": {"some": "setting"}},
+  "metrics": [
+    {
+      "groups": ["Errors"],
+      "name": "errors",
+      "query": {"type": "newrelic", "serviceType": "newrelic", "select": "SELECT count(*) FROM Transaction"},
+      "scopeName": "default",
+      "analysisConfigurations": {
+        "canary": {
+          "direction": "increase",
+          "nanStrategy": "replace",
+          "critical": true,
+          "mustHaveData": true,
+          "effectSize": {"allowedIncrease": 1.1, "allowedDecrease": 0.9, "criticalIncrease": 2, "criticalDecrease": 0.5, "measure": "meanRatio"},
+          "outliers": {"strategy": "remove", "outlierFactor": 3}
+        },
+        "otherJudge": {"threshold": 5}
+      }
+    }
+  ],
+  "templates": {"filter": "resource.type = gce_instance"},
+  "classifier": {"groupWeights": {"Errors": 100}}
+}`
+
+func TestCanaryConfigRoundTrip(t *testing.T) {
+	var cc CanaryConfig
+	assert.Nil(t, json.Unmarshal([]byte(fullCanaryConfig), &cc))
+
+	canary := cc.Metrics[0].AnalysisConfigurations.Canary
+	assert.Equal(t, DirectionIncrease, canary.Direction)
+	assert.Equal(t, NanStrategyReplace, canary.NanStrategy)
+	assert.True(t, canary.Critical)
+	assert.Equal(t, 1.1, *canary.EffectSize.AllowedIncrease)
+	assert.Equal(t, OutlierStrategyRemove, canary.Outliers.Strategy)
+
+	b, err := json.Marshal(cc)
+	assert.Nil(t, err)
+	assert.JSONEq(t, fullCanaryConfig, string(b))
+}
+
+const unmodeledCanaryConfig = `{
+  "name": "unmodeled",
+  "owner": "team-web",
+  "judge": {"name": "NetflixACAJudge-v1.0", "version": 2},
+  "metrics": [
+    {
+      "groups": ["Errors"],
+      "name": "errors",
+      "query": {"type": "newrelic", "serviceType": "newrelic", "select": "SELECT count(*) FROM Transaction"},
+      "scopeName": "default",
+      "unit": "requests",
+      "analysisConfigurations": {
+        "canary": {
+          "direction": "increase",
+          "baseline": "control",
+          "effectSize": {"allowedIncrease": 1.1, "method": "strict"},
+          "outliers": {"strategy": "remove", "windowSize": 10}
+        }
+      }
+    }
+  ],
+  "classifier": {"groupWeights": {"Errors": 100}, "scoreThresholds": {"marginal": 75, "pass": 95}}
+}`
+
+func TestCanaryConfigKeepsUnmodeledFields(t *testing.T) {
+	var cc CanaryConfig
+	assert.Nil(t, json.Unmarshal([]byte(unmodeledCanaryConfig), &cc))
+
+	assert.JSONEq(t, `{"marginal": 75, "pass": 95}`, string(cc.Classifier.Other["scoreThresholds"]))
+	assert.Equal(t, map[string]float64{"Errors": 100}, cc.Classifier.GroupWeights)
+	assert.Equal(t, 1.1, *cc.Metrics[0].AnalysisConfigurations.Canary.EffectSize.AllowedIncrease)
+	assert.Len(t, cc.Other, 1)
+
+	b, err := json.Marshal(cc)
+	assert.Nil(t, err)
+	assert.JSONEq(t, unmodeledCanaryConfig, string(b))
+
+	// modeled fields win over stale copies in Other
+	cc.Classifier.Other["groupWeights"] = json.RawMessage(`{"Errors": 1}`)
+	b, err = json.Marshal(cc.Classifier)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"groupWeights": {"Errors": 100}, "scoreThresholds": {"marginal": 75, "pass": 95}}`, string(b))
+}
+
+func TestCanaryConfigKeepsZeroValues(t *testing.T) {
+	const canary = `{
+  "direction": "either",
+  "effectSize": {"allowedIncrease": 0, "allowedDecrease": 0, "criticalIncrease": 0, "criticalDecrease": 0},
+  "outliers": {"strategy": "remove", "outlierFactor": 0}
+}`
+	var c CanaryAnalysisConfiguration
+	assert.Nil(t, json.Unmarshal([]byte(canary), &c))
+	assert.Equal(t, 0.0, *c.EffectSize.AllowedIncrease)
+	assert.Equal(t, 0.0, *c.Outliers.OutlierFactor)
+
+	b, err := json.Marshal(c)
+	assert.Nil(t, err)
+	assert.JSONEq(t, canary, string(b))
+
+	// unset sizes are left to kayenta's defaults
+	b, err = json.Marshal(EffectSize{Measure: "cles"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"measure": "cles"}`, string(b))
+}
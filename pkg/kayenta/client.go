@@ -2,12 +2,15 @@ package kayenta
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 const (
@@ -19,50 +22,23 @@ const (
 //StandaloneCanaryAnalysisInput is used to create an api request to kayenta for a standalone analysis
 type StandaloneCanaryAnalysisInput struct {
 	// Optional query parameters
-	User               string `json:"-"`
-	Application        string `json:"-"`
-	MetricsAccountName string `json:"-"`
-	StorageAccountName string `json:"-"`
+	User                      string `json:"-"`
+	Application               string `json:"-"`
+	ParentPipelineExecutionID string `json:"-"`
+	MetricsAccountName        string `json:"-"`
+	StorageAccountName        string `json:"-"`
+	ConfigurationAccountName  string `json:"-"`
 
 	// Request body
 	CanaryConfig     CanaryConfig     `json:"canaryConfig"`
 	ExecutionRequest ExecutionRequest `json:"executionRequest"`
 }
 
-type CanaryConfig struct {
-	Name             string           `json:"name"`
-	Id               string           `json:"id"`
-	Applications     []string         `json:"canaryConfig"`
-	ConfigVersion    string           `json:"configVersion"`
-	CreatedTimestamp int              `json:"createdTimestamp"`
-	Judge            JudgeConfig      `json:"judge"`
-	Metrics          []Metric         `json:"metrics"`
-	Classifier       CanaryClassifier `json:"classifier"`
-}
-
-type JudgeConfig struct {
-	Name string `json:"name"`
-}
-
-type Metric struct {
-	Groups    []string          `json:"groups"`
-	Name      string            `json:"name"`
-	Query     map[string]string `json:"query"`
-	ScopeName string            `json:"scopeName"`
-
-	AnalysisConfigurations AnalysisConfiguration `json:"analysisConfigurations"`
-}
-
-type AnalysisConfiguration map[string]interface{}
-
-type CanaryClassifier struct {
-	GroupWeights map[string]int `json:"groupWeights"`
-}
-
 type ExecutionRequest struct {
 	Scopes               []Scope `json:"scopes"`
 	LifetimeDurationMins int     `json:"lifetimeDurationMins"`
 	BeginAfterMins       int     `json:"beginAfterMins"`
+	LookbackMins         int     `json:"lookbackMins,omitempty"`
 	AnalysisIntervalMins int     `json:"analysisIntervalMins"`
 
 	Thresholds Threshold `json:"thresholds"`
@@ -97,16 +73,60 @@ type StandaloneCanaryAnalysisOutput struct {
 type StageStatus struct {
 	StageType   string `json:"type"`
 	Name        string `json:"name"`
-	Status      string `json:status"`
-	ExecutionID string `json:executionId"`
+	Status      string `json:"status"`
+	ExecutionID string `json:"executionId"`
+
+	// StartTime and EndTime are epoch milliseconds, and zero if the stage
+	// hasn't started or ended yet
+	StartTime int64        `json:"startTime,omitempty"`
+	EndTime   int64        `json:"endTime,omitempty"`
+	Context   StageContext `json:"context"`
+}
+
+// Duration is how long the stage ran for, or zero if it hasn't ended
+func (s StageStatus) Duration() time.Duration {
+	if s.StartTime == 0 || s.EndTime < s.StartTime {
+		return 0
+	}
+	return time.Duration(s.EndTime-s.StartTime) * time.Millisecond
+}
+
+// ErrorMessage is the message of the exception the stage failed with, if any
+func (s StageStatus) ErrorMessage() string {
+	exception := s.Context.Exception
+	if exception == nil {
+		return ""
+	}
+	if exception.Details.Error != "" {
+		return exception.Details.Error
+	}
+	return strings.Join(exception.Details.Errors, "; ")
+}
+
+type StageContext struct {
+	Exception *StageException `json:"exception,omitempty"`
+}
+
+type StageException struct {
+	ExceptionType string                `json:"exceptionType,omitempty"`
+	Details       StageExceptionDetails `json:"details"`
 }
+
+type StageExceptionDetails struct {
+	Error  string   `json:"error,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
 type GetStandaloneCanaryAnalysisOutput struct {
 	Status                        string                        `json:"status"`
 	ExecutionStatus               string                        `json:"executionStatus"`
 	PipelineID                    string                        `json:"pipelineId"`
+	StorageAccountName            string                        `json:"storageAccountName,omitempty"`
 	Complete                      bool                          `json:"complete"`
 	Stages                        []StageStatus                 `json:"stageStatus"`
 	CanaryAnalysisExecutionResult CanaryAnalysisExecutionResult `json:"canaryAnalysisExecutionResult"`
+	// CanaryAnalysisExecutionRequest is the request the execution was started with
+	CanaryAnalysisExecutionRequest ExecutionRequest `json:"canaryAnalysisExecutionRequest"`
 
 	// TODO - there are more things we want here
 }
@@ -123,22 +143,56 @@ type CanaryAnalysisExecutionResult struct {
 	CanaryExecutionResults []CanaryExecutionResult `json:"canaryExecutionResults"`
 }
 
+// CanaryExecutionResult is the result of one canary run, i.e. one interval of
+// the analysis
 type CanaryExecutionResult struct {
-	Result struct {
-		JudgeResult    JudgeResult `json:"judgeResult"`
-		CanaryDuration string      `json:"canaryDuration"`
-	} `json:"result"`
+	ExecutionID            string                  `json:"executionId"`
+	ExecutionStatus        string                  `json:"executionStatus"`
+	Result                 CanaryResult            `json:"result"`
+	CanaryExecutionRequest *CanaryExecutionRequest `json:"canaryExecutionRequest,omitempty"`
+	// MetricSetPairListID identifies the raw series of the run, see GetMetricSetPairList
+	MetricSetPairListID string `json:"metricSetPairListId,omitempty"`
+}
+
+// CanaryExecutionRequest holds the time windows a canary run analyzed, keyed by scope name
+type CanaryExecutionRequest struct {
+	Scopes map[string]CanaryScopePair `json:"scopes"`
+}
+
+type CanaryScopePair struct {
+	ControlScope    CanaryScope `json:"controlScope"`
+	ExperimentScope CanaryScope `json:"experimentScope"`
+}
+
+type CanaryScope struct {
+	Scope    string `json:"scope"`
+	Location string `json:"location,omitempty"`
+	// Start and End are ISO 8601 timestamps
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Step is the resolution of the metrics in seconds
+	Step int64 `json:"step,omitempty"`
+}
+
+type CanaryResult struct {
+	JudgeResult    JudgeResult `json:"judgeResult"`
+	CanaryDuration string      `json:"canaryDuration"`
 }
 
 type JudgeResult struct {
-	JudgeName string `json:"judgeName"`
-	Results   []struct {
-		Name                 string   `json:"name"`
-		Classification       string   `json:"classification"`
-		ClassificationReason string   `json:"classificationReason"`
-		Groups               []string `json:"groups"`
-	} `json:"results"`
-	GroupScores []MetricGroup `json:"groupScores"`
+	JudgeName   string         `json:"judgeName"`
+	Results     []MetricResult `json:"results"`
+	GroupScores []MetricGroup  `json:"groupScores"`
+}
+
+type MetricResult struct {
+	Name                 string   `json:"name"`
+	Classification       string   `json:"classification"`
+	ClassificationReason string   `json:"classificationReason"`
+	Groups               []string `json:"groups"`
+
+	ControlMetadata    *MetricMetadata `json:"controlMetadata,omitempty"`
+	ExperimentMetadata *MetricMetadata `json:"experimentMetadata,omitempty"`
 }
 
 type MetricGroup struct {
@@ -161,17 +215,24 @@ func (e ServerError) Error() string {
 }
 
 type CanaryConfigAPI interface {
-	UpdateCanaryConfig(cc CanaryConfig) (string, error)
-	CreateCanaryConfig(cc CanaryConfig) (string, error)
-	GetCanaryConfigs(application string) ([]CanaryConfig, error)
+	UpdateCanaryConfig(ctx context.Context, cc CanaryConfig) (string, error)
+	CreateCanaryConfig(ctx context.Context, cc CanaryConfig) (string, error)
+	GetCanaryConfigs(ctx context.Context, application string) ([]CanaryConfig, error)
+	GetCanaryConfig(ctx context.Context, id string) (CanaryConfig, error)
+	DeleteCanaryConfig(ctx context.Context, id string) error
 }
 type StandaloneCanaryAnalysisAPI interface {
-	StartStandaloneCanaryAnalysis(input StandaloneCanaryAnalysisInput) (StandaloneCanaryAnalysisOutput, error)
-	GetStandaloneCanaryAnalysis(id string) (GetStandaloneCanaryAnalysisOutput, error)
+	StartStandaloneCanaryAnalysis(ctx context.Context, input StandaloneCanaryAnalysisInput) (StandaloneCanaryAnalysisOutput, error)
+	GetStandaloneCanaryAnalysis(ctx context.Context, id string) (GetStandaloneCanaryAnalysisOutput, error)
+	CancelStandaloneCanaryAnalysis(ctx context.Context, id string) error
 }
 
 type CredentialsAPI interface {
-	GetCredentials() []AccountCredential
+	GetCredentials(ctx context.Context) ([]AccountCredential, error)
+}
+
+type MetricSetPairListAPI interface {
+	GetMetricSetPairList(ctx context.Context, id, storageAccountName string) ([]MetricSetPair, error)
 }
 
 type AccountCredential struct {
@@ -180,10 +241,14 @@ type AccountCredential struct {
 	Type           string   `json:"type"`
 }
 
+// Client is the full Kayenta API. Every method accepts a context.Context which is
+// attached to the underlying http request so that callers can cancel in-flight
+// calls or bound them with a deadline.
 type Client interface {
 	StandaloneCanaryAnalysisAPI
 	CanaryConfigAPI
 	CredentialsAPI
+	MetricSetPairListAPI
 }
 
 // HTTPClientFactory returns an http.Client that
@@ -195,9 +260,16 @@ func DefaultHTTPClientFactory() *http.Client {
 	return &http.Client{}
 }
 
+var _ Client = &DefaultClient{}
+
 type DefaultClient struct {
 	BaseURL       string
 	ClientFactory HTTPClientFactory
+	RetryPolicy   RetryPolicy
+	Authenticator Authenticator
+	// ConfigurationAccountName is the account canary configs are read from
+	// and written to. kayenta's default configuration account is used if empty
+	ConfigurationAccountName string
 }
 
 func ClientBaseURL(baseURL string) func(dc *DefaultClient) {
@@ -212,11 +284,18 @@ func ClientHTTPClientFactory(factory HTTPClientFactory) func(dc *DefaultClient)
 	}
 }
 
+func ClientConfigurationAccountName(name string) func(dc *DefaultClient) {
+	return func(dc *DefaultClient) {
+		dc.ConfigurationAccountName = name
+	}
+}
+
 func NewDefaultClient(opts ...func(dc *DefaultClient)) *DefaultClient {
 	c := &DefaultClient{
 		// TODO: replace with actual kayenta port
 		BaseURL:       "http://localhost:8090",
 		ClientFactory: DefaultHTTPClientFactory,
+		RetryPolicy:   NoRetryPolicy(),
 	}
 
 	for _, opt := range opts {
@@ -240,8 +319,13 @@ func (d *DefaultClient) getEndpoint(endpoint string, params map[string]string) s
 	return parsed.String()
 }
 
-func requestFactory(method, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
+// configParams are the query parameters of every canary config request
+func (d *DefaultClient) configParams() map[string]string {
+	return map[string]string{"configurationAccountName": d.ConfigurationAccountName}
+}
+
+func requestFactory(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return req, err
 	}
@@ -251,24 +335,27 @@ func requestFactory(method, url string, body io.Reader) (*http.Request, error) {
 }
 
 //StartStandaloneCanaryAnalysis - starts a canary analysis
-func (d *DefaultClient) StartStandaloneCanaryAnalysis(input StandaloneCanaryAnalysisInput) (StandaloneCanaryAnalysisOutput, error) {
+func (d *DefaultClient) StartStandaloneCanaryAnalysis(ctx context.Context, input StandaloneCanaryAnalysisInput) (StandaloneCanaryAnalysisOutput, error) {
 	b, err := json.Marshal(input)
 	if err != nil {
 		return StandaloneCanaryAnalysisOutput{}, fmt.Errorf("failed to marshal request input: %w", err)
 	}
 	startQueryParams := map[string]string{
-		"storageAccountName": input.StorageAccountName,
-		"metricsAccountName": input.MetricsAccountName,
-		// TODO - there are still some params missing from this
+		"user":                      input.User,
+		"application":               input.Application,
+		"parentPipelineExecutionId": input.ParentPipelineExecutionID,
+		"storageAccountName":        input.StorageAccountName,
+		"metricsAccountName":        input.MetricsAccountName,
+		"configurationAccountName":  input.ConfigurationAccountName,
 	}
 
-	req, err := requestFactory(
+	req, err := requestFactory(ctx,
 		http.MethodPost, d.getEndpoint(standaloneCanaryAnalysisEndpoint, startQueryParams), bytes.NewReader(b))
 
 	if err != nil {
 		return StandaloneCanaryAnalysisOutput{}, fmt.Errorf("failed to create request: %w", err)
 	}
-	resp, err := d.ClientFactory().Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return StandaloneCanaryAnalysisOutput{}, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -284,14 +371,14 @@ func (d *DefaultClient) StartStandaloneCanaryAnalysis(input StandaloneCanaryAnal
 	return output, nil
 }
 
-func (d *DefaultClient) GetStandaloneCanaryAnalysis(id string) (GetStandaloneCanaryAnalysisOutput, error) {
-	req, err := requestFactory(
+func (d *DefaultClient) GetStandaloneCanaryAnalysis(ctx context.Context, id string) (GetStandaloneCanaryAnalysisOutput, error) {
+	req, err := requestFactory(ctx,
 		http.MethodGet, d.getEndpoint(standaloneCanaryAnalysisEndpoint+"/"+id, nil), nil)
 
 	if err != nil {
 		return GetStandaloneCanaryAnalysisOutput{}, err
 	}
-	resp, err := d.ClientFactory().Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return GetStandaloneCanaryAnalysisOutput{}, err
 	}
@@ -307,8 +394,26 @@ func (d *DefaultClient) GetStandaloneCanaryAnalysis(id string) (GetStandaloneCan
 	return output, nil
 }
 
+//CancelStandaloneCanaryAnalysis stops a running analysis. Kayenta marks the execution as cancelled
+//and it will not produce a final result
+func (d *DefaultClient) CancelStandaloneCanaryAnalysis(ctx context.Context, id string) error {
+	req, err := requestFactory(ctx,
+		http.MethodPut, d.getEndpoint(standaloneCanaryAnalysisEndpoint+"/"+id+"/cancel", nil), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return deserializeErrorResponse(resp)
+	}
+	return resp.Body.Close()
+}
+
 //UpdateCanaryConfig updates an existing config
-func (d *DefaultClient) UpdateCanaryConfig(cc CanaryConfig) (string, error) {
+func (d *DefaultClient) UpdateCanaryConfig(ctx context.Context, cc CanaryConfig) (string, error) {
 	if cc.Id == "" {
 		return "", errors.New("Canary Config ID cannot be empty value")
 	}
@@ -317,12 +422,12 @@ func (d *DefaultClient) UpdateCanaryConfig(cc CanaryConfig) (string, error) {
 		return "", fmt.Errorf("could not marshal canary config: %w", err)
 	}
 
-	req, err := requestFactory(
-		http.MethodPut, d.getEndpoint(canaryConfigEndpoint+"/"+cc.Id, nil), bytes.NewReader(ccBytes))
+	req, err := requestFactory(ctx,
+		http.MethodPut, d.getEndpoint(canaryConfigEndpoint+"/"+cc.Id, d.configParams()), bytes.NewReader(ccBytes))
 	if err != nil {
 		return "", err
 	}
-	resp, err := d.ClientFactory().Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -337,20 +442,23 @@ func (d *DefaultClient) UpdateCanaryConfig(cc CanaryConfig) (string, error) {
 }
 
 //CreateCanaryConfig writes a canary config to object storage
-func (d *DefaultClient) CreateCanaryConfig(cc CanaryConfig) (string, error) {
+func (d *DefaultClient) CreateCanaryConfig(ctx context.Context, cc CanaryConfig) (string, error) {
 	ccBytes, err := json.Marshal(cc)
 	if err != nil {
 		return "", fmt.Errorf("could not marshal canary config: %w", err)
 	}
-	req, err := requestFactory(
-		http.MethodPost, d.getEndpoint(canaryConfigEndpoint, nil), bytes.NewReader(ccBytes))
+	req, err := requestFactory(ctx,
+		http.MethodPost, d.getEndpoint(canaryConfigEndpoint, d.configParams()), bytes.NewReader(ccBytes))
 	if err != nil {
 		return "", err
 	}
-	resp, err := d.ClientFactory().Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return "", err
 	}
+	if resp.StatusCode >= 400 {
+		return "", deserializeErrorResponse(resp)
+	}
 	var result map[string]string
 	if err := deserializeResponse(resp, &result); err != nil {
 		return "", err
@@ -358,18 +466,23 @@ func (d *DefaultClient) CreateCanaryConfig(cc CanaryConfig) (string, error) {
 	return result["canaryConfigId"], nil
 }
 
-//GetCanaryConfigs gets a list of canary configs from the Kayenta server
-func (d *DefaultClient) GetCanaryConfigs(application string) ([]CanaryConfig, error) {
+//GetCanaryConfigs gets a list of canary configs from the Kayenta server, only those of the application if it isn't empty
+func (d *DefaultClient) GetCanaryConfigs(ctx context.Context, application string) ([]CanaryConfig, error) {
+	params := d.configParams()
+	params["application"] = application
 
-	req, err := http.NewRequest(
-		http.MethodGet, d.getEndpoint(canaryConfigEndpoint, nil), nil)
+	req, err := requestFactory(ctx,
+		http.MethodGet, d.getEndpoint(canaryConfigEndpoint, params), nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := d.ClientFactory().Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode >= 400 {
+		return nil, deserializeErrorResponse(resp)
+	}
 	var output []CanaryConfig
 	if err := deserializeResponse(resp, &output); err != nil {
 		return nil, err
@@ -378,16 +491,61 @@ func (d *DefaultClient) GetCanaryConfigs(application string) ([]CanaryConfig, er
 
 }
 
-func (d *DefaultClient) GetCredentials() ([]AccountCredential, error) {
-	req, err := http.NewRequest(
+//GetCanaryConfig gets a single canary config by id
+func (d *DefaultClient) GetCanaryConfig(ctx context.Context, id string) (CanaryConfig, error) {
+	req, err := requestFactory(ctx,
+		http.MethodGet, d.getEndpoint(canaryConfigEndpoint+"/"+id, d.configParams()), nil)
+	if err != nil {
+		return CanaryConfig{}, err
+	}
+	resp, err := d.do(req)
+	if err != nil {
+		return CanaryConfig{}, err
+	}
+	if resp.StatusCode >= 400 {
+		return CanaryConfig{}, deserializeErrorResponse(resp)
+	}
+	var output CanaryConfig
+	if err := deserializeResponse(resp, &output); err != nil {
+		return CanaryConfig{}, err
+	}
+	if output.Id == "" {
+		// kayenta doesn't include the id in the stored document
+		output.Id = id
+	}
+	return output, nil
+}
+
+//DeleteCanaryConfig removes a canary config from object storage
+func (d *DefaultClient) DeleteCanaryConfig(ctx context.Context, id string) error {
+	req, err := requestFactory(ctx,
+		http.MethodDelete, d.getEndpoint(canaryConfigEndpoint+"/"+id, d.configParams()), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return deserializeErrorResponse(resp)
+	}
+	return resp.Body.Close()
+}
+
+func (d *DefaultClient) GetCredentials(ctx context.Context) ([]AccountCredential, error) {
+	req, err := requestFactory(ctx,
 		http.MethodGet, d.getEndpoint(credentialsEndpoint, nil), nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := d.ClientFactory().Do(req)
+	resp, err := d.do(req)
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode >= 400 {
+		return nil, deserializeErrorResponse(resp)
+	}
 	var output []AccountCredential
 	if err := deserializeResponse(resp, &output); err != nil {
 		return nil, err
@@ -398,8 +556,10 @@ func (d *DefaultClient) GetCredentials() ([]AccountCredential, error) {
 
 func deserializeErrorResponse(resp *http.Response) error {
 	var e ServerError
+	// proxies in front of kayenta don't necessarily respond with json, the
+	// status code is still worth reporting in that case
 	if err := deserializeResponse(resp, &e); err != nil {
-		return err
+		e = ServerError{}
 	}
 	e.Code = resp.StatusCode
 	return e
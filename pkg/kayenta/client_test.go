@@ -1,9 +1,14 @@
 package kayenta
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,20 +26,90 @@ func TestUpdateCanaryConfigs(t *testing.T) {
 	var cc CanaryConfig
 	json.Unmarshal([]byte(testConfig), &cc)
 
-	id, err := c.UpdateCanaryConfig(cc)
+	id, err := c.UpdateCanaryConfig(context.Background(), cc)
 	assert.Nil(t, err)
 	assert.NotEqual(t, id, "")
 }
 
 func TestGetCanaryConfigs(t *testing.T) {
-	//SkipIntegration(t)
+	SkipIntegration(t)
 	c := NewDefaultClient(ClientBaseURL("http://localhost:8090"))
-	cc, err := c.GetCanaryConfigs("somename")
+	cc, err := c.GetCanaryConfigs(context.Background(), "somename")
 	assert.Nil(t, err)
 	assert.NotNil(t, cc)
 	assert.True(t, len(cc) > 0)
 }
 
+func TestCancelStandaloneCanaryAnalysis(t *testing.T) {
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL))
+	err := c.CancelStandaloneCanaryAnalysis(context.Background(), "some-id")
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/standalone_canary_analysis/some-id/cancel", path)
+}
+
+func TestStartStandaloneCanaryAnalysisQueryParams(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"canaryAnalysisExecutionId": "some-id"}`))
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL))
+	output, err := c.StartStandaloneCanaryAnalysis(context.Background(), StandaloneCanaryAnalysisInput{
+		User:                      "jane",
+		Application:               "app",
+		ParentPipelineExecutionID: "pipeline",
+		MetricsAccountName:        "metrics",
+		ConfigurationAccountName:  "configs",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "some-id", output.CanaryAnalysisExecutionID)
+	assert.Equal(t, url.Values{
+		"user":                      {"jane"},
+		"application":               {"app"},
+		"parentPipelineExecutionId": {"pipeline"},
+		"metricsAccountName":        {"metrics"},
+		"configurationAccountName":  {"configs"},
+	}, query)
+}
+
+func TestCanaryConfigQueryParams(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if r.URL.Path == "/canaryConfig" {
+			w.Write([]byte(`[{"id": "some-id", "name": "some-config"}]`))
+			return
+		}
+		w.Write([]byte(`{"name": "some-config"}`))
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientConfigurationAccountName("configs"))
+	cc, err := c.GetCanaryConfig(context.Background(), "some-id")
+	assert.Nil(t, err)
+	assert.Equal(t, "some-id", cc.Id)
+	assert.Equal(t, url.Values{"configurationAccountName": {"configs"}}, query)
+
+	configs, err := c.GetCanaryConfigs(context.Background(), "app")
+	assert.Nil(t, err)
+	assert.Len(t, configs, 1)
+	assert.Equal(t, url.Values{"configurationAccountName": {"configs"}, "application": {"app"}}, query)
+
+	c = NewDefaultClient(ClientBaseURL(server.URL))
+	_, err = c.GetCanaryConfig(context.Background(), "some-id")
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{}, query)
+}
+
 const testConfig string = `{
 	"applications": [
 	  "beats"
@@ -64,3 +139,36 @@ const testConfig string = `{
 	],
 	"name": "hello-world2"
   }`
+
+func TestNonJSONErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>bad gateway</html>"))
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL))
+	_, err := c.GetStandaloneCanaryAnalysis(context.Background(), "some-id")
+	assert.Equal(t, ServerError{Code: http.StatusBadGateway}, err)
+}
+
+func TestStageStatus(t *testing.T) {
+	var stage StageStatus
+	err := json.Unmarshal([]byte(`{
+		"type": "runCanary",
+		"name": "Run Canary #1",
+		"status": "TERMINAL",
+		"executionId": "some-id",
+		"startTime": 1600000000000,
+		"endTime": 1600000090000,
+		"context": {"exception": {"exceptionType": "IllegalStateException", "details": {"errors": ["no data", "no metrics"]}}}
+	}`), &stage)
+	assert.Nil(t, err)
+	assert.Equal(t, "TERMINAL", stage.Status)
+	assert.Equal(t, "some-id", stage.ExecutionID)
+	assert.Equal(t, 90*time.Second, stage.Duration())
+	assert.Equal(t, "no data; no metrics", stage.ErrorMessage())
+
+	assert.Equal(t, time.Duration(0), StageStatus{StartTime: 1600000000000}.Duration())
+	assert.Equal(t, "", StageStatus{}.ErrorMessage())
+}
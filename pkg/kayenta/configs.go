@@ -1,20 +1,107 @@
 package kayenta
 
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
 //There is a decent change we may not need this entire module as we can embed the config in the analysis call
 
-//UpsertCanaryConfigs adds additional logic around the Kayenta service since it does not allow for upserts
-func UpsertCanaryConfigs(d *DefaultClient, application string, cc CanaryConfig) (string, error) {
-	cc.Applications = []string{application}
+// ConflictError is returned when a canary config was changed in kayenta after
+// it was read, so writing it would overwrite someone else's changes
+type ConflictError struct {
+	ID, Name string
+	// Expected is the updatedTimestamp of the config when it was read, and
+	// Actual its updatedTimestamp in kayenta now
+	Expected, Actual int64
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("canary config %s (%s) was changed in kayenta at %s, after it was read at %s",
+		e.Name, e.ID, formatMillis(e.Actual), formatMillis(e.Expected))
+}
+
+func formatMillis(ms int64) string {
+	if ms == 0 {
+		return "an unknown time"
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+//UpsertCanaryConfigs adds additional logic around the Kayenta service since it does not allow for upserts.
+//The config of the application with the same name as cc is updated, or cc is created if there is none.
+//If cc has an updatedTimestamp, e.g. because it was fetched from kayenta and edited, the update fails with
+//a ConflictError when the config in kayenta has been updated since. The application is added to the
+//applications of cc if it's missing, and an updated config keeps the applications it already had, so that
+//configs shared by several applications stay shared.
+func UpsertCanaryConfigs(ctx context.Context, api CanaryConfigAPI, application string, cc CanaryConfig) (string, error) {
+	cc.Applications = appendMissing(nil, append(cc.Applications, application)...)
 
-	configs, err := d.GetCanaryConfigs(application)
+	configs, err := api.GetCanaryConfigs(ctx, application)
 	if err != nil {
 		return "", err
 	}
 
-	if len(configs) == 0 {
-		return d.CreateCanaryConfig(cc)
+	var matches []CanaryConfig
+	for _, existing := range configs {
+		if existing.Name == cc.Name && containsString(existing.Applications, application) {
+			matches = append(matches, existing)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		cc.Id = ""
+		return api.CreateCanaryConfig(ctx, cc)
+	case 1:
+	default:
+		var ids []string
+		for _, match := range matches {
+			ids = append(ids, match.Id)
+		}
+		return "", fmt.Errorf("application %s has %d canary configs named %s: %s", application, len(matches), cc.Name, strings.Join(ids, ", "))
+	}
+
+	expected := cc.UpdatedTimestamp
+	if expected == 0 {
+		expected = matches[0].UpdatedTimestamp
+	}
+	cc.Id = matches[0].Id
+	cc.Applications = appendMissing(cc.Applications, matches[0].Applications...)
+	return UpdateCanaryConfigIfUnchanged(ctx, api, cc, expected)
+}
+
+// UpdateCanaryConfigIfUnchanged updates cc only if the config in kayenta still
+// has the given updatedTimestamp, the way an If-Match header would. kayenta
+// has no conditional writes, so there is a short window between the check and
+// the update in which a concurrent change is still lost.
+func UpdateCanaryConfigIfUnchanged(ctx context.Context, api CanaryConfigAPI, cc CanaryConfig, updatedTimestamp int64) (string, error) {
+	current, err := api.GetCanaryConfig(ctx, cc.Id)
+	if err != nil {
+		return "", err
+	}
+	if current.UpdatedTimestamp != updatedTimestamp {
+		return "", ConflictError{ID: cc.Id, Name: cc.Name, Expected: updatedTimestamp, Actual: current.UpdatedTimestamp}
+	}
+	return api.UpdateCanaryConfig(ctx, cc)
+}
+
+// appendMissing appends the values that aren't in values yet
+func appendMissing(values []string, more ...string) []string {
+	for _, v := range more {
+		if !containsString(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	//TODO make sure this line is tested
-	cc.Id = configs[0].Id
-	return d.UpdateCanaryConfig(cc)
+	return false
 }
@@ -1,17 +1,112 @@
-package kayenta
+package kayenta_test
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/armory-io/kayentactl/pkg/kayenta/kayentatest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestUpsertCanaryConfigs(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(
+		kayenta.CanaryConfig{Name: "latency", Applications: []string{"other"}},
+	))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
 
-	c := NewDefaultClient(ClientBaseURL("http://localhost:8090"))
-	var cc CanaryConfig
-	json.Unmarshal([]byte(testConfig), &cc)
-	UpsertCanaryConfigs(c, "somename", cc)
-	assert.Equal(t, cc.Id, "hello")
+	latencyID, err := kayenta.UpsertCanaryConfigs(ctx, c, "app", kayenta.CanaryConfig{Name: "latency"})
+	assert.Nil(t, err)
+	errorsID, err := kayenta.UpsertCanaryConfigs(ctx, c, "app", kayenta.CanaryConfig{Name: "errors"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, latencyID, errorsID)
+	assert.Len(t, server.CanaryConfigs(), 3)
+
+	id, err := kayenta.UpsertCanaryConfigs(ctx, c, "app", kayenta.CanaryConfig{Name: "latency", Description: "updated"})
+	assert.Nil(t, err)
+	assert.Equal(t, latencyID, id)
+	assert.Len(t, server.CanaryConfigs(), 3)
+
+	updated, err := c.GetCanaryConfig(ctx, latencyID)
+	assert.Nil(t, err)
+	assert.Equal(t, "updated", updated.Description)
+	assert.Equal(t, []string{"app"}, updated.Applications)
+
+	others, err := c.GetCanaryConfigs(ctx, "other")
+	assert.Nil(t, err)
+	assert.Len(t, others, 1)
+	assert.Equal(t, "", others[0].Description)
+}
+
+func TestUpsertCanaryConfigsSharedConfig(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(
+		kayenta.CanaryConfig{Name: "latency", Applications: []string{"web", "api"}},
+	))
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	// pushing for one application keeps the config shared with the others
+	id, err := kayenta.UpsertCanaryConfigs(ctx, c, "api", kayenta.CanaryConfig{Name: "latency", Description: "updated"})
+	assert.Nil(t, err)
+	updated, err := c.GetCanaryConfig(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "updated", updated.Description)
+	assert.Equal(t, []string{"api", "web"}, updated.Applications)
+
+	// the application is added to a config that lists others
+	id, err = kayenta.UpsertCanaryConfigs(ctx, c, "web", kayenta.CanaryConfig{Name: "errors", Applications: []string{"api"}})
+	assert.Nil(t, err)
+	created, err := c.GetCanaryConfig(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api", "web"}, created.Applications)
+}
+
+func TestUpsertCanaryConfigsConflict(t *testing.T) {
+	server := kayentatest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	id, err := kayenta.UpsertCanaryConfigs(ctx, c, "app", kayenta.CanaryConfig{Name: "latency"})
+	assert.Nil(t, err)
+	read, err := c.GetCanaryConfig(ctx, id)
+	assert.Nil(t, err)
+	assert.NotZero(t, read.UpdatedTimestamp)
+
+	// someone else changes the config after it was read
+	_, err = kayenta.UpsertCanaryConfigs(ctx, c, "app", kayenta.CanaryConfig{Name: "latency", Description: "theirs"})
+	assert.Nil(t, err)
+
+	read.Description = "mine"
+	_, err = kayenta.UpsertCanaryConfigs(ctx, c, "app", read)
+	var conflict kayenta.ConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, id, conflict.ID)
+	assert.Equal(t, read.UpdatedTimestamp, conflict.Expected)
+	assert.Greater(t, conflict.Actual, conflict.Expected)
+
+	current, err := c.GetCanaryConfig(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "theirs", current.Description)
+
+	// a config read after the change can be written
+	current.Description = "mine"
+	_, err = kayenta.UpsertCanaryConfigs(ctx, c, "app", current)
+	assert.Nil(t, err)
+}
+
+func TestUpsertCanaryConfigsDuplicateNames(t *testing.T) {
+	server := kayentatest.NewServer(kayentatest.WithCanaryConfigs(
+		kayenta.CanaryConfig{Name: "latency", Applications: []string{"app"}},
+		kayenta.CanaryConfig{Name: "latency", Applications: []string{"app"}},
+	))
+	defer server.Close()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+
+	_, err := kayenta.UpsertCanaryConfigs(context.Background(), c, "app", kayenta.CanaryConfig{Name: "latency"})
+	assert.EqualError(t, err, "application app has 2 canary configs named latency: config-1, config-2")
 }
@@ -0,0 +1,111 @@
+package kayentatest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+const metricSetPairListEndpoint = "/metricSetPairList"
+
+// pointsPerRun is the number of data points in each series of a canary run
+const pointsPerRun = 10
+
+// experimentFactors scale the experiment's series relative to the control's
+// so that the statistics match the classification the scenario asks for
+var experimentFactors = map[string]float64{
+	"Pass": 1.02,
+	"High": 1.5,
+	"Low":  0.5,
+}
+
+// metricSetPairListID identifies the series of the run-th canary run
+func (e *execution) metricSetPairListID(run int) string {
+	return fmt.Sprintf("%s-run-%d", e.id, run+1)
+}
+
+// metricSetPairs generates the series of every metric of the canary config for
+// the run-th canary run. Metrics classified as Nodata have no experiment data.
+func (e *execution) metricSetPairs(run int) []kayenta.MetricSetPair {
+	start := e.startTime + int64(run)*stageDuration
+	step := int64(stageDuration / pointsPerRun)
+
+	var pairs []kayenta.MetricSetPair
+	for i, metric := range e.input.CanaryConfig.Metrics {
+		factor, ok := experimentFactors[e.classification(metric.Name)]
+		if !ok {
+			factor = math.NaN()
+		}
+
+		var control, experiment []kayenta.Float
+		for j := 0; j < pointsPerRun; j++ {
+			value := 100 + 10*math.Sin(float64(i+j+run))
+			control = append(control, kayenta.Float(value))
+			experiment = append(experiment, kayenta.Float(value*factor))
+		}
+
+		scope := kayenta.MetricSetScope{StepMillis: step, StartTimeMillis: start}
+		pairs = append(pairs, kayenta.MetricSetPair{
+			Name:   metric.Name,
+			ID:     fmt.Sprintf("%s-%d", e.metricSetPairListID(run), i),
+			Values: map[string][]kayenta.Float{"control": control, "experiment": experiment},
+			Scopes: map[string]kayenta.MetricSetScope{"control": scope, "experiment": scope},
+		})
+	}
+	return pairs
+}
+
+// statistics summarizes a series like the judge does, ignoring missing data
+func statistics(series []kayenta.Float) *kayenta.MetricStatistics {
+	var values []float64
+	for _, v := range series {
+		if !math.IsNaN(float64(v)) {
+			values = append(values, float64(v))
+		}
+	}
+	stats := &kayenta.MetricStatistics{Count: len(values)}
+	if len(values) == 0 {
+		nan := kayenta.Float(math.NaN())
+		stats.Mean, stats.Min, stats.Max, stats.Median = nan, nan, nan, &nan
+		return stats
+	}
+
+	sort.Float64s(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+	}
+	m := kayenta.Float(median)
+	stats.Mean = kayenta.Float(sum / float64(len(values)))
+	stats.Min = kayenta.Float(values[0])
+	stats.Max = kayenta.Float(values[len(values)-1])
+	stats.Median = &m
+	return stats
+}
+
+func (s *Server) getMetricSetPairList(w http.ResponseWriter, id string) {
+	notFound := func() {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("metric set pair list %s not found", id))
+	}
+	i := strings.LastIndex(id, "-run-")
+	if i < 0 {
+		notFound()
+		return
+	}
+	e, ok := s.executions[id[:i]]
+	run, err := strconv.Atoi(id[i+len("-run-"):])
+	if !ok || err != nil || run < 1 || run > len(e.completedScores()) {
+		notFound()
+		return
+	}
+	writeJSON(w, http.StatusOK, e.metricSetPairs(run-1))
+}
@@ -0,0 +1,305 @@
+package kayentatest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+const (
+	StatusNotStarted = "NOT_STARTED"
+	StatusRunning    = "RUNNING"
+	StatusSucceeded  = "SUCCEEDED"
+	StatusTerminal   = "TERMINAL"
+	StatusCanceled   = "CANCELED"
+)
+
+// Scenario scripts the lifecycle of executions started on the fake server.
+// An execution has one runCanary stage per score followed by a stage that
+// generates the final result. Every time the execution is fetched the current
+// stage is reported as RUNNING until it has been polled PollsPerStage times,
+// after which it is SUCCEEDED and the next stage starts.
+type Scenario struct {
+	// PollsPerStage is the number of fetches each stage stays RUNNING for
+	PollsPerStage int
+	// Scores is the score of each canary run. The last one is the final score.
+	Scores []float64
+	// Classifications maps metric names from the canary config to the
+	// classification the judge gives them. Unlisted metrics are classified as Pass.
+	Classifications map[string]string
+	// Terminal makes the last canary run fail with TerminalMessage instead of
+	// producing a result
+	Terminal        bool
+	TerminalMessage string
+}
+
+// DefaultScenario is a passing execution with a single canary run
+func DefaultScenario() Scenario {
+	return Scenario{
+		PollsPerStage: 1,
+		Scores:        []float64{100},
+	}
+}
+
+type execution struct {
+	id       string
+	input    kayenta.StandaloneCanaryAnalysisInput
+	scenario Scenario
+	stages   []kayenta.StageStatus
+
+	// polls is the number of times the execution has been fetched
+	polls    int
+	canceled bool
+	// startTime is when the execution was started, in epoch milliseconds
+	startTime int64
+}
+
+// stageDuration is how long each stage appears to take, in milliseconds
+const stageDuration = 60 * 1000
+
+func newExecution(id string, input kayenta.StandaloneCanaryAnalysisInput, scenario Scenario) *execution {
+	if scenario.PollsPerStage < 1 {
+		scenario.PollsPerStage = 1
+	}
+	if len(scenario.Scores) == 0 {
+		scenario.Scores = []float64{100}
+	}
+
+	e := &execution{id: id, input: input, scenario: scenario, startTime: time.Now().UnixNano() / int64(time.Millisecond)}
+	for i := range scenario.Scores {
+		e.stages = append(e.stages, kayenta.StageStatus{
+			StageType: "runCanary",
+			Name:      fmt.Sprintf("Run Canary #%d", i+1),
+		})
+	}
+	e.stages = append(e.stages, kayenta.StageStatus{
+		StageType: "generateCanaryAnalysisResult",
+		Name:      "Generate Canary Analysis Result",
+	})
+	return e
+}
+
+func (e *execution) advance() {
+	if !e.complete() {
+		e.polls++
+	}
+}
+
+func (e *execution) cancel() {
+	if !e.complete() {
+		e.canceled = true
+	}
+}
+
+// currentStage is the index of the stage that is running
+func (e *execution) currentStage() int {
+	if e.polls == 0 {
+		return 0
+	}
+	return (e.polls - 1) / e.scenario.PollsPerStage
+}
+
+// failedStage is the index of the stage that fails in a terminal scenario
+func (e *execution) failedStage() int {
+	return len(e.scenario.Scores) - 1
+}
+
+func (e *execution) complete() bool {
+	if e.canceled {
+		return true
+	}
+	if e.scenario.Terminal {
+		return e.currentStage() > e.failedStage()
+	}
+	return e.currentStage() >= len(e.stages)
+}
+
+func (e *execution) status() string {
+	switch {
+	case e.canceled:
+		return StatusCanceled
+	case !e.complete():
+		return StatusRunning
+	case e.scenario.Terminal:
+		return StatusTerminal
+	case !e.passed():
+		return StatusTerminal
+	default:
+		return StatusSucceeded
+	}
+}
+
+func (e *execution) passed() bool {
+	scores := e.completedScores()
+	return len(scores) > 0 && scores[len(scores)-1] >= parseThreshold(e.input.ExecutionRequest.Thresholds.Pass)
+}
+
+// completedScores are the scores of the canary runs that have finished
+func (e *execution) completedScores() []float64 {
+	completed := e.currentStage()
+	if e.scenario.Terminal && completed > e.failedStage() {
+		completed = e.failedStage()
+	}
+	if completed > len(e.scenario.Scores) {
+		completed = len(e.scenario.Scores)
+	}
+	return e.scenario.Scores[:completed]
+}
+
+func (e *execution) output() kayenta.GetStandaloneCanaryAnalysisOutput {
+	status := e.status()
+	output := kayenta.GetStandaloneCanaryAnalysisOutput{
+		Status:          strings.ToLower(status),
+		ExecutionStatus: status,
+		PipelineID:      e.id,
+		Complete:        e.complete(),
+
+		CanaryAnalysisExecutionRequest: e.input.ExecutionRequest,
+	}
+
+	current := e.currentStage()
+	for i, stage := range e.stages {
+		switch {
+		case e.scenario.Terminal && e.complete() && i == e.failedStage():
+			stage.Status = StatusTerminal
+			stage.Context.Exception = &kayenta.StageException{
+				ExceptionType: "IllegalStateException",
+				Details:       kayenta.StageExceptionDetails{Error: e.scenario.TerminalMessage},
+			}
+		case e.scenario.Terminal && e.complete() && i > e.failedStage():
+			stage.Status = StatusNotStarted
+		case i < current:
+			stage.Status = StatusSucceeded
+		case i == current && e.canceled:
+			stage.Status = StatusCanceled
+		case i == current:
+			stage.Status = StatusRunning
+		default:
+			stage.Status = StatusNotStarted
+		}
+		// every stage pretends to take a minute
+		if stage.Status != StatusNotStarted {
+			stage.StartTime = e.startTime + int64(i)*stageDuration
+		}
+		if stage.Status == StatusSucceeded || stage.Status == StatusTerminal {
+			stage.EndTime = stage.StartTime + stageDuration
+		}
+		output.Stages = append(output.Stages, stage)
+	}
+
+	scores := e.completedScores()
+	result := kayenta.CanaryAnalysisExecutionResult{CanaryScores: scores}
+	for i := range scores {
+		result.CanaryExecutionResults = append(result.CanaryExecutionResults, kayenta.CanaryExecutionResult{
+			ExecutionID:            fmt.Sprintf("%s-run-%d", e.id, i+1),
+			ExecutionStatus:        StatusSucceeded,
+			Result:                 kayenta.CanaryResult{JudgeResult: e.judgeResult(i)},
+			CanaryExecutionRequest: e.canaryExecutionRequest(i),
+			MetricSetPairListID:    e.metricSetPairListID(i),
+		})
+	}
+	if e.complete() && !e.canceled && !e.scenario.Terminal {
+		result.DidPassThresholds = e.passed()
+		result.CanaryScoreMessage = e.scoreMessage(scores[len(scores)-1])
+	}
+	if e.scenario.Terminal && e.complete() {
+		result.CanaryScoreMessage = e.scenario.TerminalMessage
+	}
+	output.CanaryAnalysisExecutionResult = result
+	return output
+}
+
+func (e *execution) scoreMessage(score float64) string {
+	thresholds := e.input.ExecutionRequest.Thresholds
+	if e.passed() {
+		return fmt.Sprintf("Final canary score %.1f met or exceeded the pass score threshold.", score)
+	}
+	if score >= parseThreshold(thresholds.Marginal) {
+		return fmt.Sprintf("Final canary score %.1f is not above the pass score threshold.", score)
+	}
+	return fmt.Sprintf("Final canary score %.1f is not above the marginal score threshold.", score)
+}
+
+// judgeResult classifies every metric of the canary config according to the
+// scenario, with statistics of the series of the run-th canary run. Group
+// scores are the percentage of metrics in the group that pass.
+func (e *execution) judgeResult(run int) kayenta.JudgeResult {
+	result := kayenta.JudgeResult{JudgeName: e.input.CanaryConfig.Judge.Name}
+	passed, total := map[string]int{}, map[string]int{}
+	var groups []string
+
+	pairs := e.metricSetPairs(run)
+	for i, metric := range e.input.CanaryConfig.Metrics {
+		classification := e.classification(metric.Name)
+		reason := ""
+		if classification != "Pass" {
+			reason = fmt.Sprintf("The metric was classified as %s", classification)
+		}
+		result.Results = append(result.Results, kayenta.MetricResult{
+			Name:                 metric.Name,
+			Classification:       classification,
+			ClassificationReason: reason,
+			Groups:               metric.Groups,
+			ControlMetadata:      &kayenta.MetricMetadata{Stats: statistics(pairs[i].Control())},
+			ExperimentMetadata:   &kayenta.MetricMetadata{Stats: statistics(pairs[i].Experiment())},
+		})
+
+		for _, group := range metric.Groups {
+			if _, seen := total[group]; !seen {
+				groups = append(groups, group)
+			}
+			total[group]++
+			if classification == "Pass" {
+				passed[group]++
+			}
+		}
+	}
+
+	for _, group := range groups {
+		result.GroupScores = append(result.GroupScores, kayenta.MetricGroup{
+			Name:  group,
+			Score: 100 * float64(passed[group]) / float64(total[group]),
+		})
+	}
+	return result
+}
+
+// classification is how the scenario classifies a metric, Pass by default
+func (e *execution) classification(metric string) string {
+	if classification, ok := e.scenario.Classifications[metric]; ok {
+		return classification
+	}
+	return "Pass"
+}
+
+// canaryExecutionRequest is the request of the i-th canary run. each run
+// analyzes the minute during which its stage ran
+func (e *execution) canaryExecutionRequest(i int) *kayenta.CanaryExecutionRequest {
+	start := time.Unix(0, (e.startTime+int64(i)*stageDuration)*int64(time.Millisecond)).UTC()
+	end := start.Add(stageDuration * time.Millisecond)
+
+	scopes := e.input.ExecutionRequest.Scopes
+	if len(scopes) == 0 {
+		scopes = []kayenta.Scope{{ScopeName: "default"}}
+	}
+	request := &kayenta.CanaryExecutionRequest{Scopes: map[string]kayenta.CanaryScopePair{}}
+	for _, scope := range scopes {
+		request.Scopes[scope.ScopeName] = kayenta.CanaryScopePair{
+			ControlScope: kayenta.CanaryScope{
+				Scope:    scope.ControlScope,
+				Location: scope.ControlLocation,
+				Start:    start.Format(time.RFC3339),
+				End:      end.Format(time.RFC3339),
+			},
+			ExperimentScope: kayenta.CanaryScope{
+				Scope:    scope.ExperimentScope,
+				Location: scope.ExperimentLocation,
+				Start:    start.Format(time.RFC3339),
+				End:      end.Format(time.RFC3339),
+			},
+		}
+	}
+	return request
+}
@@ -0,0 +1,344 @@
+// Package kayentatest provides an in-process fake Kayenta server for testing
+// code that uses kayenta.Client, and for developing kayentactl without a real
+// Kayenta installation.
+package kayentatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+)
+
+const (
+	canaryConfigEndpoint             = "/canaryConfig"
+	standaloneCanaryAnalysisEndpoint = "/standalone_canary_analysis"
+	credentialsEndpoint              = "/credentials"
+)
+
+// StartRequest is a request to start an analysis received by the server
+type StartRequest struct {
+	Query url.Values
+	Input kayenta.StandaloneCanaryAnalysisInput
+}
+
+type injectedError struct {
+	prefix, message string
+	status, count   int
+}
+
+// Server is a fake Kayenta. Executions started on it progress through their
+// stages each time they are fetched, following the server's Scenario.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	scenario    Scenario
+	latency     time.Duration
+	errors      []*injectedError
+	credentials []kayenta.AccountCredential
+	configs     map[string]kayenta.CanaryConfig
+	executions  map[string]*execution
+	started     []StartRequest
+	nextID      int
+	lastUpdate  int64
+}
+
+// NewServer starts a fake Kayenta server. The caller should call Close when
+// finished to shut it down.
+func NewServer(opts ...func(s *Server)) *Server {
+	s := &Server{
+		scenario:   DefaultScenario(),
+		configs:    map[string]kayenta.CanaryConfig{},
+		executions: map[string]*execution{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// WithScenario sets the Scenario followed by executions started on the server
+func WithScenario(scenario Scenario) func(s *Server) {
+	return func(s *Server) {
+		s.scenario = scenario
+	}
+}
+
+// WithLatency delays every response from the server
+func WithLatency(latency time.Duration) func(s *Server) {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithCredentials sets the accounts returned from the credentials endpoint
+func WithCredentials(credentials ...kayenta.AccountCredential) func(s *Server) {
+	return func(s *Server) {
+		s.credentials = credentials
+	}
+}
+
+// WithCanaryConfigs stores canary configs on the server. Configs without an id
+// are assigned one.
+func WithCanaryConfigs(configs ...kayenta.CanaryConfig) func(s *Server) {
+	return func(s *Server) {
+		for _, cc := range configs {
+			s.storeConfig(cc)
+		}
+	}
+}
+
+// SetScenario changes the Scenario for executions started after the call
+func (s *Server) SetScenario(scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario = scenario
+}
+
+// SetLatency changes how long every response from the server is delayed
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// InjectError makes the next count requests whose path starts with prefix fail
+// with the given status code and message
+func (s *Server) InjectError(prefix string, status, count int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, &injectedError{prefix: prefix, status: status, count: count, message: message})
+}
+
+// StartRequests returns every request to start an analysis received so far
+func (s *Server) StartRequests() []StartRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StartRequest(nil), s.started...)
+}
+
+// CanaryConfigs returns the canary configs stored on the server, sorted by id
+func (s *Server) CanaryConfigs() []kayenta.CanaryConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedConfigs("")
+}
+
+// Execution returns the current state of an execution without advancing it
+func (s *Server) Execution(id string) (kayenta.GetStandaloneCanaryAnalysisOutput, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.executions[id]
+	if !ok {
+		return kayenta.GetStandaloneCanaryAnalysisOutput{}, false
+	}
+	return e.output(), true
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.latency
+	injected := s.takeInjectedError(r.URL.Path)
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if injected != nil {
+		writeError(w, injected.status, injected.message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == standaloneCanaryAnalysisEndpoint && r.Method == http.MethodPost:
+		s.startAnalysis(w, r)
+	case strings.HasPrefix(path, standaloneCanaryAnalysisEndpoint+"/") && strings.HasSuffix(path, "/cancel") && r.Method == http.MethodPut:
+		s.cancelAnalysis(w, strings.TrimSuffix(strings.TrimPrefix(path, standaloneCanaryAnalysisEndpoint+"/"), "/cancel"))
+	case strings.HasPrefix(path, standaloneCanaryAnalysisEndpoint+"/") && r.Method == http.MethodGet:
+		s.getAnalysis(w, strings.TrimPrefix(path, standaloneCanaryAnalysisEndpoint+"/"))
+	case path == canaryConfigEndpoint && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.sortedConfigs(r.URL.Query().Get("application")))
+	case path == canaryConfigEndpoint && r.Method == http.MethodPost:
+		s.createConfig(w, r)
+	case strings.HasPrefix(path, canaryConfigEndpoint+"/"):
+		s.handleConfig(w, r, strings.TrimPrefix(path, canaryConfigEndpoint+"/"))
+	case strings.HasPrefix(path, metricSetPairListEndpoint+"/") && r.Method == http.MethodGet:
+		s.getMetricSetPairList(w, strings.TrimPrefix(path, metricSetPairListEndpoint+"/"))
+	case path == credentialsEndpoint && r.Method == http.MethodGet:
+		credentials := s.credentials
+		if credentials == nil {
+			credentials = []kayenta.AccountCredential{}
+		}
+		writeJSON(w, http.StatusOK, credentials)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no handler for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) takeInjectedError(path string) *injectedError {
+	for i, e := range s.errors {
+		if !strings.HasPrefix(path, e.prefix) {
+			continue
+		}
+		e.count--
+		if e.count <= 0 {
+			s.errors = append(s.errors[:i], s.errors[i+1:]...)
+		}
+		return e
+	}
+	return nil
+}
+
+func (s *Server) startAnalysis(w http.ResponseWriter, r *http.Request) {
+	var input kayenta.StandaloneCanaryAnalysisInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.started = append(s.started, StartRequest{Query: r.URL.Query(), Input: input})
+
+	s.nextID++
+	id := fmt.Sprintf("execution-%d", s.nextID)
+	s.executions[id] = newExecution(id, input, s.scenario)
+	writeJSON(w, http.StatusOK, kayenta.StandaloneCanaryAnalysisOutput{CanaryAnalysisExecutionID: id})
+}
+
+func (s *Server) getAnalysis(w http.ResponseWriter, id string) {
+	e, ok := s.executions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("execution %s not found", id))
+		return
+	}
+	e.advance()
+	writeJSON(w, http.StatusOK, e.output())
+}
+
+func (s *Server) cancelAnalysis(w http.ResponseWriter, id string) {
+	e, ok := s.executions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("execution %s not found", id))
+		return
+	}
+	e.cancel()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) createConfig(w http.ResponseWriter, r *http.Request) {
+	var cc kayenta.CanaryConfig
+	if err := json.NewDecoder(r.Body).Decode(&cc); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cc.Id = ""
+	id := s.storeConfig(cc)
+	writeJSON(w, http.StatusOK, map[string]string{"canaryConfigId": id})
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request, id string) {
+	existing, ok := s.configs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("canary config %s not found", id))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, existing)
+	case http.MethodPut:
+		var cc kayenta.CanaryConfig
+		if err := json.NewDecoder(r.Body).Decode(&cc); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		cc.Id = id
+		s.storeConfig(cc)
+		writeJSON(w, http.StatusOK, map[string]string{"canaryConfigId": id})
+	case http.MethodDelete:
+		delete(s.configs, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not supported", r.Method))
+	}
+}
+
+// storeConfig stamps the config with its created and updated times like
+// kayenta does. updatedTimestamp increases on every write, even within the
+// same millisecond, so that clients can detect concurrent changes.
+func (s *Server) storeConfig(cc kayenta.CanaryConfig) string {
+	if cc.Id == "" {
+		s.nextID++
+		cc.Id = fmt.Sprintf("config-%d", s.nextID)
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if now <= s.lastUpdate {
+		now = s.lastUpdate + 1
+	}
+	s.lastUpdate = now
+	if existing, ok := s.configs[cc.Id]; ok {
+		cc.CreatedTimestamp, cc.CreatedTimestampIso = existing.CreatedTimestamp, existing.CreatedTimestampIso
+	} else {
+		cc.CreatedTimestamp, cc.CreatedTimestampIso = now, isoMillis(now)
+	}
+	cc.UpdatedTimestamp, cc.UpdatedTimestampIso = now, isoMillis(now)
+
+	s.configs[cc.Id] = cc
+	return cc.Id
+}
+
+func isoMillis(ms int64) string {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func (s *Server) sortedConfigs(application string) []kayenta.CanaryConfig {
+	configs := []kayenta.CanaryConfig{}
+	for _, cc := range s.configs {
+		if application == "" || contains(cc.Applications, application) {
+			configs = append(configs, cc)
+		}
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Id < configs[j].Id })
+	return configs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parseThreshold(threshold string) float64 {
+	v, _ := strconv.ParseFloat(threshold, 64)
+	return v
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, kayenta.ServerError{Message: message})
+}
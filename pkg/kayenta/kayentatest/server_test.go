@@ -0,0 +1,151 @@
+package kayentatest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/armory-io/kayentactl/pkg/kayenta"
+	"github.com/stretchr/testify/assert"
+)
+
+func testInput() kayenta.StandaloneCanaryAnalysisInput {
+	return kayenta.StandaloneCanaryAnalysisInput{
+		MetricsAccountName: "metrics",
+		CanaryConfig: kayenta.CanaryConfig{
+			Metrics: []kayenta.Metric{
+				{Name: "cpu", Groups: []string{"system"}},
+				{Name: "latency", Groups: []string{"system"}},
+			},
+		},
+		ExecutionRequest: kayenta.ExecutionRequest{
+			Thresholds: kayenta.Threshold{Marginal: "50", Pass: "90"},
+		},
+	}
+}
+
+func TestExecutionLifecycle(t *testing.T) {
+	server := NewServer(WithScenario(Scenario{
+		PollsPerStage:   2,
+		Scores:          []float64{100, 50},
+		Classifications: map[string]string{"latency": "High"},
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	started, err := c.StartStandaloneCanaryAnalysis(ctx, testInput())
+	assert.Nil(t, err)
+	assert.Equal(t, "metrics", server.StartRequests()[0].Query.Get("metricsAccountName"))
+
+	var statuses [][]string
+	var output kayenta.GetStandaloneCanaryAnalysisOutput
+	for !output.Complete {
+		output, err = c.GetStandaloneCanaryAnalysis(ctx, started.CanaryAnalysisExecutionID)
+		assert.Nil(t, err)
+		var s []string
+		for _, stage := range output.Stages {
+			s = append(s, stage.Status)
+		}
+		statuses = append(statuses, s)
+	}
+
+	assert.Equal(t, [][]string{
+		{StatusRunning, StatusNotStarted, StatusNotStarted},
+		{StatusRunning, StatusNotStarted, StatusNotStarted},
+		{StatusSucceeded, StatusRunning, StatusNotStarted},
+		{StatusSucceeded, StatusRunning, StatusNotStarted},
+		{StatusSucceeded, StatusSucceeded, StatusRunning},
+		{StatusSucceeded, StatusSucceeded, StatusRunning},
+		{StatusSucceeded, StatusSucceeded, StatusSucceeded},
+	}, statuses)
+	assert.Equal(t, "TERMINAL", output.ExecutionStatus)
+	assert.False(t, output.CanaryAnalysisExecutionResult.DidPassThresholds)
+	assert.Equal(t, []float64{100, 50}, output.CanaryAnalysisExecutionResult.CanaryScores)
+
+	judgeResult := output.CanaryAnalysisExecutionResult.CanaryExecutionResults[1].Result.JudgeResult
+	assert.Equal(t, "High", judgeResult.Results[1].Classification)
+	assert.Equal(t, []kayenta.MetricGroup{{Name: "system", Score: 50}}, judgeResult.GroupScores)
+
+	difference, ok := judgeResult.Results[1].RelativeDifference()
+	assert.True(t, ok)
+	assert.InDelta(t, 50, difference, 0.001)
+
+	listID := output.CanaryAnalysisExecutionResult.CanaryExecutionResults[1].MetricSetPairListID
+	pairs, err := c.GetMetricSetPairList(ctx, listID, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "latency", pairs[1].Name)
+	assert.Len(t, pairs[1].Experiment(), 10)
+
+	_, err = c.GetMetricSetPairList(ctx, started.CanaryAnalysisExecutionID+"-run-3", "")
+	assert.Equal(t, http.StatusNotFound, err.(kayenta.ServerError).Code)
+}
+
+func TestTerminalExecution(t *testing.T) {
+	server := NewServer(WithScenario(Scenario{Scores: []float64{100, 100}, Terminal: true, TerminalMessage: "no data"}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	started, _ := c.StartStandaloneCanaryAnalysis(ctx, testInput())
+
+	var output kayenta.GetStandaloneCanaryAnalysisOutput
+	for !output.Complete {
+		output, _ = c.GetStandaloneCanaryAnalysis(ctx, started.CanaryAnalysisExecutionID)
+	}
+	assert.Equal(t, "TERMINAL", output.ExecutionStatus)
+	assert.Equal(t, StatusTerminal, output.Stages[1].Status)
+	assert.Equal(t, "no data", output.Stages[1].ErrorMessage())
+	assert.Equal(t, time.Minute, output.Stages[0].Duration())
+	assert.Equal(t, "no data", output.CanaryAnalysisExecutionResult.CanaryScoreMessage)
+}
+
+func TestCancelExecution(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	started, _ := c.StartStandaloneCanaryAnalysis(ctx, testInput())
+	assert.Nil(t, c.CancelStandaloneCanaryAnalysis(ctx, started.CanaryAnalysisExecutionID))
+
+	output, _ := server.Execution(started.CanaryAnalysisExecutionID)
+	assert.True(t, output.Complete)
+	assert.Equal(t, "canceled", output.Status)
+}
+
+func TestInjectedErrors(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.InjectError("/credentials", http.StatusServiceUnavailable, 1, "unavailable")
+
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	_, err := c.GetCredentials(context.Background())
+	assert.Equal(t, kayenta.ServerError{Code: http.StatusServiceUnavailable, Message: "unavailable"}, err)
+
+	_, err = c.GetCredentials(context.Background())
+	assert.Nil(t, err)
+}
+
+func TestCanaryConfigs(t *testing.T) {
+	server := NewServer(WithCanaryConfigs(kayenta.CanaryConfig{Name: "existing", Applications: []string{"other"}}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := kayenta.NewDefaultClient(kayenta.ClientBaseURL(server.URL))
+	id, err := c.CreateCanaryConfig(ctx, kayenta.CanaryConfig{Name: "new", Applications: []string{"app"}})
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", id)
+	assert.Len(t, server.CanaryConfigs(), 2)
+
+	cc, err := c.GetCanaryConfig(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", cc.Name)
+	assert.Equal(t, id, cc.Id)
+
+	assert.Nil(t, c.DeleteCanaryConfig(ctx, id))
+	assert.Len(t, server.CanaryConfigs(), 1)
+	_, err = c.GetCanaryConfig(ctx, id)
+	assert.Equal(t, http.StatusNotFound, err.(kayenta.ServerError).Code)
+}
@@ -0,0 +1,159 @@
+package kayenta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const metricSetPairListEndpoint = "/metricSetPairList"
+
+// Float is a number that Kayenta may send as "NaN", "Infinity" or
+// "-Infinity", which encoding/json can't handle on its own. null is decoded
+// as NaN.
+type Float float64
+
+func (f Float) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(v)
+}
+
+func (f *Float) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*f = Float(math.NaN())
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		*f = Float(v)
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*f = Float(v)
+	return nil
+}
+
+// MetricStatistics summarizes the values of a metric for the control or the
+// experiment, as computed by the judge
+type MetricStatistics struct {
+	Count int   `json:"count"`
+	Mean  Float `json:"mean"`
+	Min   Float `json:"min"`
+	Max   Float `json:"max"`
+	// Median and Std are only sent by some judges
+	Median *Float `json:"median,omitempty"`
+	Std    *Float `json:"std,omitempty"`
+}
+
+// MetricMetadata is what the judge reports about the control or experiment
+// series of a metric
+type MetricMetadata struct {
+	Stats *MetricStatistics `json:"stats,omitempty"`
+}
+
+// Control is the statistics of the control series, nil if the judge didn't
+// report any
+func (m MetricResult) Control() *MetricStatistics {
+	if m.ControlMetadata == nil {
+		return nil
+	}
+	return m.ControlMetadata.Stats
+}
+
+// Experiment is the statistics of the experiment series, nil if the judge
+// didn't report any
+func (m MetricResult) Experiment() *MetricStatistics {
+	if m.ExperimentMetadata == nil {
+		return nil
+	}
+	return m.ExperimentMetadata.Stats
+}
+
+// RelativeDifference is how much the experiment's mean differs from the
+// control's, as a percentage of the control's mean. It is false if there are
+// no statistics or the difference is undefined because the control's mean is 0.
+func (m MetricResult) RelativeDifference() (float64, bool) {
+	if m.Control() == nil || m.Experiment() == nil {
+		return 0, false
+	}
+	control, experiment := float64(m.Control().Mean), float64(m.Experiment().Mean)
+	if math.IsNaN(control) || math.IsNaN(experiment) {
+		return 0, false
+	}
+	if control == 0 {
+		return 0, experiment == 0
+	}
+	return (experiment - control) / math.Abs(control) * 100, true
+}
+
+// MetricSetPair is the raw series of a metric for the control and the
+// experiment during one canary run
+type MetricSetPair struct {
+	Name string            `json:"name"`
+	ID   string            `json:"id"`
+	Tags map[string]string `json:"tags,omitempty"`
+	// Values are keyed by control and experiment. missing data points are NaN
+	Values map[string][]Float `json:"values"`
+	// Scopes describe the time range of the values, keyed by control and experiment
+	Scopes     map[string]MetricSetScope    `json:"scopes,omitempty"`
+	Attributes map[string]map[string]string `json:"attributes,omitempty"`
+}
+
+type MetricSetScope struct {
+	StartTimeIso    string `json:"startTimeIso"`
+	StartTimeMillis int64  `json:"startTimeMillis"`
+	StepMillis      int64  `json:"stepMillis"`
+}
+
+// Control is the series of the control
+func (p MetricSetPair) Control() []Float {
+	return p.Values["control"]
+}
+
+// Experiment is the series of the experiment
+func (p MetricSetPair) Experiment() []Float {
+	return p.Values["experiment"]
+}
+
+// GetMetricSetPairList fetches the raw series of every metric of a canary
+// run, using the MetricSetPairListID of its CanaryExecutionResult
+func (d *DefaultClient) GetMetricSetPairList(ctx context.Context, id, storageAccountName string) ([]MetricSetPair, error) {
+	req, err := requestFactory(ctx,
+		http.MethodGet,
+		d.getEndpoint(metricSetPairListEndpoint+"/"+id, map[string]string{"accountName": storageAccountName}),
+		nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, deserializeErrorResponse(resp)
+	}
+
+	var output []MetricSetPair
+	if err := deserializeResponse(resp, &output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,129 @@
+package kayenta
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat(t *testing.T) {
+	var values []Float
+	err := json.Unmarshal([]byte(`[1.5, "NaN", null, "Infinity", "-Infinity", "2"]`), &values)
+	assert.Nil(t, err)
+	assert.Equal(t, Float(1.5), values[0])
+	assert.True(t, math.IsNaN(float64(values[1])))
+	assert.True(t, math.IsNaN(float64(values[2])))
+	assert.True(t, math.IsInf(float64(values[3]), 1))
+	assert.True(t, math.IsInf(float64(values[4]), -1))
+	assert.Equal(t, Float(2), values[5])
+
+	b, err := json.Marshal(values)
+	assert.Nil(t, err)
+	assert.Equal(t, `[1.5,"NaN","NaN","Infinity","-Infinity",2]`, string(b))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`"many"`), &values[0]))
+}
+
+func TestRelativeDifference(t *testing.T) {
+	tests := map[string]struct {
+		control, experiment *MetricStatistics
+		difference          float64
+		ok                  bool
+	}{
+		"increase":      {control: &MetricStatistics{Mean: 10}, experiment: &MetricStatistics{Mean: 15}, difference: 50, ok: true},
+		"decrease":      {control: &MetricStatistics{Mean: -10}, experiment: &MetricStatistics{Mean: -15}, difference: -50, ok: true},
+		"both zero":     {control: &MetricStatistics{Mean: 0}, experiment: &MetricStatistics{Mean: 0}, difference: 0, ok: true},
+		"zero control":  {control: &MetricStatistics{Mean: 0}, experiment: &MetricStatistics{Mean: 1}},
+		"no data":       {control: &MetricStatistics{Mean: 1}, experiment: &MetricStatistics{Mean: Float(math.NaN())}},
+		"no statistics": {},
+		"no experiment": {control: &MetricStatistics{Mean: 1}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := MetricResult{ControlMetadata: &MetricMetadata{Stats: test.control}, ExperimentMetadata: &MetricMetadata{Stats: test.experiment}}
+			difference, ok := result.RelativeDifference()
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.difference, difference)
+		})
+	}
+}
+
+// judgeResult is the judgeResult of a canary run as NetflixACAJudge-v1.0
+// reports it: the statistics of each series live in controlMetadata.stats and
+// experimentMetadata.stats, next to resultMetadata
+const judgeResult = `{
+  "judgeName": "NetflixACAJudge-v1.0",
+  "results": [
+    {
+      "name": "cpu",
+      "id": "0c3e0d32-7c2b-4f2e-9d3a-5a2f0d9c1e7b",
+      "classification": "High",
+      "classificationReason": "The metric was classified as High",
+      "groups": ["system"],
+      "experimentMetadata": {"stats": {"count": 60, "mean": 0.75, "min": 0.5, "max": 0.98, "median": 0.74}},
+      "controlMetadata": {"stats": {"count": 60, "mean": 0.5, "min": 0.31, "max": 0.66, "median": 0.51}},
+      "resultMetadata": {"ratio": 1.5},
+      "critical": false
+    },
+    {
+      "name": "errors",
+      "id": "8d1e5b0a-2c4f-4a4e-b1f9-3f6c7d2e9a10",
+      "classification": "Nodata",
+      "classificationReason": "Missing data",
+      "groups": ["requests"],
+      "experimentMetadata": {"stats": {"count": 0, "mean": "NaN", "min": "NaN", "max": "NaN", "median": "NaN"}},
+      "controlMetadata": {"stats": {"count": 0, "mean": "NaN", "min": "NaN", "max": "NaN", "median": "NaN"}},
+      "resultMetadata": {},
+      "critical": false
+    }
+  ],
+  "groupScores": [
+    {"name": "system", "score": 0.0, "classification": "", "classificationReason": ""},
+    {"name": "requests", "score": 100.0, "classification": "", "classificationReason": ""}
+  ],
+  "score": {"score": 50.0, "classification": "Marginal", "classificationReason": ""}
+}`
+
+func TestJudgeResultStatistics(t *testing.T) {
+	var result JudgeResult
+	assert.Nil(t, json.Unmarshal([]byte(judgeResult), &result))
+
+	cpu := result.Results[0]
+	assert.Equal(t, 60, cpu.Control().Count)
+	assert.Equal(t, Float(0.5), cpu.Control().Mean)
+	assert.Equal(t, Float(0.98), cpu.Experiment().Max)
+	assert.Equal(t, Float(0.74), *cpu.Experiment().Median)
+	difference, ok := cpu.RelativeDifference()
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, difference)
+
+	errors := result.Results[1]
+	assert.Equal(t, 0, errors.Experiment().Count)
+	assert.True(t, math.IsNaN(float64(errors.Experiment().Mean)))
+	_, ok = errors.RelativeDifference()
+	assert.False(t, ok)
+}
+
+func TestGetMetricSetPairList(t *testing.T) {
+	var path, account string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, account = r.URL.Path, r.URL.Query().Get("accountName")
+		w.Write([]byte(`[{"name": "cpu", "id": "pair-1", "values": {"control": [1, 2], "experiment": ["NaN", 3]},
+			"scopes": {"control": {"startTimeIso": "2020-12-20T14:00:00Z", "startTimeMillis": 1608472800000, "stepMillis": 60000}}}]`))
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL))
+	pairs, err := c.GetMetricSetPairList(context.Background(), "list-1", "storage")
+	assert.Nil(t, err)
+	assert.Equal(t, "/metricSetPairList/list-1", path)
+	assert.Equal(t, "storage", account)
+	assert.Equal(t, []Float{1, 2}, pairs[0].Control())
+	assert.True(t, math.IsNaN(float64(pairs[0].Experiment()[0])))
+	assert.Equal(t, int64(60000), pairs[0].Scopes["control"].StepMillis)
+}
@@ -0,0 +1,275 @@
+package kayenta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Query is the metrics provider specific part of a metric. Kayenta tells the
+// providers apart using the type field of the query, which is added when a
+// MetricQuery is marshaled.
+type Query interface {
+	// Type is the metrics provider the query is for, e.g. prometheus
+	Type() string
+	// Validate checks that the fields the provider requires are set
+	Validate() error
+}
+
+// queryTypes maps the type of a query to a constructor for its typed struct
+var queryTypes = map[string]func() Query{
+	"prometheus":  func() Query { return &PrometheusQuery{} },
+	"datadog":     func() Query { return &DatadogQuery{} },
+	"newrelic":    func() Query { return &NewRelicQuery{} },
+	"stackdriver": func() Query { return &StackdriverQuery{} },
+	"signalfx":    func() Query { return &SignalFxQuery{} },
+	"wavefront":   func() Query { return &WavefrontQuery{} },
+	"graphite":    func() Query { return &GraphiteQuery{} },
+	"atlas":       func() Query { return &AtlasQuery{} },
+}
+
+// MetricQuery wraps a Query so that it can be marshaled to and from Kayenta's
+// polymorphic query JSON. Queries for providers that aren't typed are kept as
+// an UnknownQuery so that they are passed to Kayenta untouched.
+type MetricQuery struct {
+	Query
+	// Other holds the fields of a typed query that its struct doesn't model,
+	// which are written back as-is
+	Other map[string]json.RawMessage
+}
+
+func (m MetricQuery) MarshalJSON() ([]byte, error) {
+	if m.Query == nil {
+		return []byte("null"), nil
+	}
+	if unknown, ok := m.Query.(*UnknownQuery); ok {
+		return json.Marshal(unknown.Fields)
+	}
+
+	b, err := json.Marshal(m.Query)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for k, raw := range m.Other {
+		if _, ok := fields[k]; !ok {
+			fields[k] = raw
+		}
+	}
+	fields["type"] = m.Query.Type()
+	fields["serviceType"] = m.Query.Type()
+	return json.Marshal(fields)
+}
+
+func (m *MetricQuery) UnmarshalJSON(b []byte) error {
+	var discriminator struct {
+		Type        string `json:"type"`
+		ServiceType string `json:"serviceType"`
+	}
+	if err := json.Unmarshal(b, &discriminator); err != nil {
+		return err
+	}
+	queryType := discriminator.Type
+	if queryType == "" {
+		queryType = discriminator.ServiceType
+	}
+
+	newQuery, ok := queryTypes[queryType]
+	if !ok {
+		unknown := &UnknownQuery{Kind: queryType}
+		if err := json.Unmarshal(b, &unknown.Fields); err != nil {
+			return err
+		}
+		m.Query = unknown
+		return nil
+	}
+
+	query := newQuery()
+	other, err := unmarshalWithOther(b, query)
+	if err != nil {
+		return fmt.Errorf("invalid %s query: %w", queryType, err)
+	}
+	delete(other, "type")
+	delete(other, "serviceType")
+	if len(other) == 0 {
+		other = nil
+	}
+	m.Query, m.Other = query, other
+	return nil
+}
+
+// UnknownQuery is a query for a metrics provider that kayentactl doesn't have
+// a type for. It is not validated.
+type UnknownQuery struct {
+	Kind   string
+	Fields map[string]interface{}
+}
+
+func (q *UnknownQuery) Type() string {
+	return q.Kind
+}
+
+func (q *UnknownQuery) Validate() error {
+	if q.Kind == "" {
+		return errors.New("query type is required")
+	}
+	return nil
+}
+
+type PrometheusQuery struct {
+	ResourceType         string   `json:"resourceType,omitempty"`
+	MetricName           string   `json:"metricName,omitempty"`
+	LabelBindings        []string `json:"labelBindings,omitempty"`
+	GroupByFields        []string `json:"groupByFields,omitempty"`
+	CustomInlineTemplate string   `json:"customInlineTemplate,omitempty"`
+	CustomFilter         string   `json:"customFilter,omitempty"`
+	CustomFilterTemplate string   `json:"customFilterTemplate,omitempty"`
+}
+
+func (q *PrometheusQuery) Type() string {
+	return "prometheus"
+}
+
+func (q *PrometheusQuery) Validate() error {
+	if q.MetricName == "" && q.CustomInlineTemplate == "" {
+		return errors.New("prometheus query requires metricName or customInlineTemplate")
+	}
+	return nil
+}
+
+type DatadogQuery struct {
+	MetricName           string `json:"metricName,omitempty"`
+	CustomInlineTemplate string `json:"customInlineTemplate,omitempty"`
+	CustomFilterTemplate string `json:"customFilterTemplate,omitempty"`
+}
+
+func (q *DatadogQuery) Type() string {
+	return "datadog"
+}
+
+func (q *DatadogQuery) Validate() error {
+	if q.MetricName == "" && q.CustomInlineTemplate == "" {
+		return errors.New("datadog query requires metricName or customInlineTemplate")
+	}
+	return nil
+}
+
+type NewRelicQuery struct {
+	Select               string `json:"select,omitempty"`
+	Q                    string `json:"q,omitempty"`
+	CustomInlineTemplate string `json:"customInlineTemplate,omitempty"`
+	CustomFilterTemplate string `json:"customFilterTemplate,omitempty"`
+}
+
+func (q *NewRelicQuery) Type() string {
+	return "newrelic"
+}
+
+func (q *NewRelicQuery) Validate() error {
+	if q.Select == "" && q.CustomInlineTemplate == "" {
+		return errors.New("newrelic query requires select or customInlineTemplate")
+	}
+	return nil
+}
+
+type StackdriverQuery struct {
+	ResourceType         string   `json:"resourceType,omitempty"`
+	MetricType           string   `json:"metricType,omitempty"`
+	CrossSeriesReducer   string   `json:"crossSeriesReducer,omitempty"`
+	PerSeriesAligner     string   `json:"perSeriesAligner,omitempty"`
+	GroupByFields        []string `json:"groupByFields,omitempty"`
+	CustomFilter         string   `json:"customFilter,omitempty"`
+	CustomFilterTemplate string   `json:"customFilterTemplate,omitempty"`
+}
+
+func (q *StackdriverQuery) Type() string {
+	return "stackdriver"
+}
+
+func (q *StackdriverQuery) Validate() error {
+	if q.MetricType == "" {
+		return errors.New("stackdriver query requires metricType")
+	}
+	return nil
+}
+
+// QueryPair is a dimension filter of a SignalFx query
+type QueryPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type SignalFxQuery struct {
+	MetricName           string      `json:"metricName,omitempty"`
+	AggregationMethod    string      `json:"aggregationMethod,omitempty"`
+	QueryPairs           []QueryPair `json:"queryPairs,omitempty"`
+	CustomInlineTemplate string      `json:"customInlineTemplate,omitempty"`
+	CustomFilterTemplate string      `json:"customFilterTemplate,omitempty"`
+}
+
+func (q *SignalFxQuery) Type() string {
+	return "signalfx"
+}
+
+func (q *SignalFxQuery) Validate() error {
+	if q.MetricName == "" && q.CustomInlineTemplate == "" {
+		return errors.New("signalfx query requires metricName or customInlineTemplate")
+	}
+	for _, pair := range q.QueryPairs {
+		if pair.Key == "" {
+			return errors.New("signalfx query pairs require a key")
+		}
+	}
+	return nil
+}
+
+type WavefrontQuery struct {
+	MetricName    string `json:"metricName,omitempty"`
+	Aggregate     string `json:"aggregate,omitempty"`
+	Summarization string `json:"summarization,omitempty"`
+	Granularity   string `json:"granularity,omitempty"`
+}
+
+func (q *WavefrontQuery) Type() string {
+	return "wavefront"
+}
+
+func (q *WavefrontQuery) Validate() error {
+	if q.MetricName == "" {
+		return errors.New("wavefront query requires metricName")
+	}
+	return nil
+}
+
+type GraphiteQuery struct {
+	MetricName string `json:"metricName,omitempty"`
+}
+
+func (q *GraphiteQuery) Type() string {
+	return "graphite"
+}
+
+func (q *GraphiteQuery) Validate() error {
+	if q.MetricName == "" {
+		return errors.New("graphite query requires metricName")
+	}
+	return nil
+}
+
+type AtlasQuery struct {
+	Q string `json:"q,omitempty"`
+}
+
+func (q *AtlasQuery) Type() string {
+	return "atlas"
+}
+
+func (q *AtlasQuery) Validate() error {
+	if q.Q == "" {
+		return errors.New("atlas query requires q")
+	}
+	return nil
+}
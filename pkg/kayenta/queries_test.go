@@ -0,0 +1,67 @@
+package kayenta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricQueryUnmarshalsTypedQueries(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected Query
+	}{
+		{
+			json:     `{"type": "prometheus", "metricName": "up", "customInlineTemplate": "PromQL:up", "groupByFields": ["pod"]}`,
+			expected: &PrometheusQuery{MetricName: "up", CustomInlineTemplate: "PromQL:up", GroupByFields: []string{"pod"}},
+		},
+		{
+			json:     `{"serviceType": "stackdriver", "metricType": "compute.googleapis.com/instance/cpu/utilization", "groupByFields": ["zone"]}`,
+			expected: &StackdriverQuery{MetricType: "compute.googleapis.com/instance/cpu/utilization", GroupByFields: []string{"zone"}},
+		},
+		{
+			json:     `{"type": "signalfx", "metricName": "cpu", "queryPairs": [{"key": "app", "value": "web"}]}`,
+			expected: &SignalFxQuery{MetricName: "cpu", QueryPairs: []QueryPair{{Key: "app", Value: "web"}}},
+		},
+		{
+			json:     `{"type": "influxdb", "metricName": "cpu", "fields": ["usage"]}`,
+			expected: &UnknownQuery{Kind: "influxdb", Fields: map[string]interface{}{"type": "influxdb", "metricName": "cpu", "fields": []interface{}{"usage"}}},
+		},
+	}
+
+	for _, test := range tests {
+		var q MetricQuery
+		assert.Nil(t, json.Unmarshal([]byte(test.json), &q))
+		assert.Equal(t, test.expected, q.Query)
+	}
+}
+
+func TestMetricQueryKeepsUnmodeledFields(t *testing.T) {
+	const query = `{"type": "prometheus", "serviceType": "prometheus", "metricName": "up", "metricNmae": "typo", "step": 30}`
+	var q MetricQuery
+	assert.Nil(t, json.Unmarshal([]byte(query), &q))
+	assert.Equal(t, &PrometheusQuery{MetricName: "up"}, q.Query)
+	assert.Equal(t, map[string]json.RawMessage{"metricNmae": json.RawMessage(`"typo"`), "step": json.RawMessage(`30`)}, q.Other)
+
+	b, err := json.Marshal(q)
+	assert.Nil(t, err)
+	assert.JSONEq(t, query, string(b))
+}
+
+func TestMetricQueryMarshalsTypeDiscriminator(t *testing.T) {
+	b, err := json.Marshal(MetricQuery{Query: &DatadogQuery{MetricName: "avg:system.cpu.user"}})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"type": "datadog", "serviceType": "datadog", "metricName": "avg:system.cpu.user"}`, string(b))
+}
+
+func TestCanaryConfigValidate(t *testing.T) {
+	cc := CanaryConfig{Metrics: []Metric{
+		{Name: "cpu", Query: MetricQuery{Query: &WavefrontQuery{MetricName: "cpu"}}},
+		{Name: "errors", Query: MetricQuery{Query: &NewRelicQuery{Q: "status >= 500"}}},
+	}}
+	assert.EqualError(t, cc.Validate(), `metric "errors" has an invalid query: newrelic query requires select or customInlineTemplate`)
+
+	cc.Metrics[1].Query = MetricQuery{Query: &NewRelicQuery{Select: "SELECT count(*) FROM Transaction"}}
+	assert.Nil(t, cc.Validate())
+}
@@ -0,0 +1,182 @@
+package kayenta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how the DefaultClient retries requests that fail
+// because of transient problems talking to Kayenta, e.g. a connection reset
+// or a 502/503 from a load balancer in front of it.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request,
+	// including the first one. Values below 1 are treated as 1 (no retries).
+	MaxAttempts int
+	// InitialBackoff is the time waited before the first retry. Each
+	// subsequent retry waits Multiplier times longer, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction (0-1) of each backoff that is randomized so
+	// that many clients retrying at once don't hit Kayenta in lockstep.
+	Jitter float64
+	// RetryableStatusCodes are the response codes that are considered transient.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows requests that are not idempotent (i.e. POST)
+	// to be retried after they may have reached Kayenta. This can result in
+	// duplicate analysis executions or configs being created. When false,
+	// those requests are only retried if the connection could not be established.
+	RetryNonIdempotent bool
+}
+
+// NoRetryPolicy returns a policy that makes a single attempt per request
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// DefaultRetryPolicy returns a policy suitable for most Kayenta installations
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Second,
+		MaxBackoff:           30 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.5,
+		RetryableStatusCodes: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+func ClientRetryPolicy(policy RetryPolicy) func(dc *DefaultClient) {
+	return func(dc *DefaultClient) {
+		dc.RetryPolicy = policy
+	}
+}
+
+// Backoff returns how long to wait before making the given retry attempt,
+// where attempt 1 is the first retry.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		backoff = backoff*(1-jitter) + rand.Float64()*backoff*jitter
+	}
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	retryable := isIdempotent(req.Method) || p.RetryNonIdempotent
+
+	if err != nil {
+		if retryable {
+			return true
+		}
+		// if the connection was never established kayenta did not receive
+		// the request, so it is always safe to send it again
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+
+	if !retryable {
+		return false
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// do executes the request, authenticating it with the client's Authenticator
+// and retrying according to the client's RetryPolicy
+func (d *DefaultClient) do(req *http.Request) (*http.Response, error) {
+	client := d.ClientFactory()
+	reauthenticated := false
+	for attempt := 1; ; {
+		if d.Authenticator != nil {
+			if err := d.Authenticator.Authenticate(req); err != nil {
+				return nil, fmt.Errorf("failed to authenticate request: %w", err)
+			}
+		}
+
+		resp, err := client.Do(req)
+
+		// cached credentials may have been revoked or expired early, so
+		// get fresh ones and try again once without counting it as a retry
+		invalidator, canInvalidate := d.Authenticator.(Invalidator)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && canInvalidate && !reauthenticated {
+			reauthenticated = true
+			invalidator.Invalidate()
+			if err := discardAndRewind(req, resp); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if attempt >= d.RetryPolicy.MaxAttempts || !d.RetryPolicy.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		if err := discardAndRewind(req, resp); err != nil {
+			return nil, err
+		}
+		if err := sleepContext(req.Context(), d.RetryPolicy.Backoff(attempt)); err != nil {
+			return nil, err
+		}
+		attempt++
+	}
+}
+
+// discardAndRewind closes a response that won't be returned to the caller and
+// resets the request body so that the request can be sent again
+func discardAndRewind(req *http.Request, resp *http.Response) error {
+	if resp != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
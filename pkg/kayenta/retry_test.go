@@ -0,0 +1,79 @@
+package kayenta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	return policy
+}
+
+func TestRetryTransientGetFailures(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"complete": true}`))
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientRetryPolicy(testRetryPolicy()))
+	output, err := c.GetStandaloneCanaryAnalysis(context.Background(), "some-id")
+	assert.Nil(t, err)
+	assert.True(t, output.Complete)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientRetryPolicy(testRetryPolicy()))
+	_, err := c.GetStandaloneCanaryAnalysis(context.Background(), "some-id")
+	assert.Equal(t, ServerError{Code: http.StatusBadGateway}, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryDoesNotDuplicateAnalysisStart(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := NewDefaultClient(ClientBaseURL(server.URL), ClientRetryPolicy(testRetryPolicy()))
+	_, err := c.StartStandaloneCanaryAnalysis(context.Background(), StandaloneCanaryAnalysisInput{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, calls)
+
+	policy := testRetryPolicy()
+	policy.RetryNonIdempotent = true
+	calls = 0
+	c = NewDefaultClient(ClientBaseURL(server.URL), ClientRetryPolicy(policy))
+	_, err = c.StartStandaloneCanaryAnalysis(context.Background(), StandaloneCanaryAnalysisInput{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 5*time.Second, policy.Backoff(10))
+}